@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/snocorp/golox/lox"
+)
+
+// benchFile runs the script at path repeatedly, each time in a new VM set up
+// by configure, and reports the time and allocations of one run. The
+// script's output is discarded. Like runFile, it returns an error only if the
+// script can't be read.
+func (r *runner) benchFile(path string, configure func(vm *lox.VM)) (status, error) {
+	// Report a missing file before running anything.
+	_, err := os.Stat(path)
+	if err != nil {
+		return statusOK, err
+	}
+
+	var runErr error
+	result := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N && runErr == nil; i++ {
+			vm := lox.New()
+			configure(vm)
+			vm.SetOutput(io.Discard)
+			runErr = vm.RunFile(path)
+		}
+	})
+
+	if runErr != nil {
+		return r.report(runErr), nil
+	}
+
+	fmt.Fprintf(r.stdout, "%v\t%v\t%v\n", path, result, result.MemString())
+	return statusOK, nil
+}
@@ -22,8 +22,13 @@ func main() {
 		"Call     : callee Expr[T], paren *token, arguments []Expr[T]",
 		"Get      : object Expr[T], name *token",
 		"Grouping : expression Expr[T]",
+		"Index    : object Expr[T], bracket *token, index Expr[T]",
+		"IndexSet : object Expr[T], bracket *token, index Expr[T], value Expr[T]",
+		"Lambda   : keyword *token, function *Function[T]",
+		"List     : bracket *token, elements []Expr[T]",
 		"Literal  : value any",
 		"Logical  : left Expr[T], operator *token, right Expr[T]",
+		"Map      : brace *token, keys []Expr[T], values []Expr[T]",
 		"Set      : object Expr[T], name *token, value Expr[T]",
 		"Super    : keyword *token, method *token",
 		"This     : keyword *token",
@@ -37,14 +42,20 @@ func main() {
 
 	err = defineAst(outputDir, "Stmt", "error", []string{
 		"Block      : statements []Stmt[T]",
+		"Break      : keyword *token",
 		"Class      : name *token, superclass *Variable[T], methods []*Function[T]",
+		"Continue   : keyword *token",
 		"Expression : expression Expr[T]",
+		"ForIn      : name *token, keyword *token, iterable Expr[T], body Stmt[T]",
 		"Function   : name *token, params []*token, body []Stmt[T]",
 		"If         : condition Expr[T], thenBranch Stmt[T], elseBranch Stmt[T]",
+		"Import     : keyword *token, names []*token, path *token",
 		"Print      : expression Expr[T]",
 		"Return     : keyword *token, value Expr[T]",
+		"Throw      : keyword *token, value Expr[T]",
+		"Try        : tryBranch *Block[T], catchName *token, catchBranch *Block[T], finallyBranch *Block[T]",
 		"Var        : name *token, initializer Expr[T]",
-		"While      : condition Expr[T], body Stmt[T]",
+		"While      : condition Expr[T], body Stmt[T], increment Expr[T]",
 	})
 	if err != nil {
 		fmt.Println(err)
@@ -63,7 +74,7 @@ func defineAst(outputDir, baseName, acceptReturn string, types []string) error {
 	defer f.Close()
 
 	lines := []string{
-		"package main",
+		"package lox",
 		"",
 		fmt.Sprintf("type %s[T any] interface {", baseName),
 		fmt.Sprintf("\taccept(v Visitor[T]) %s", acceptReturn),
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/snocorp/golox/lox"
+)
+
+// A conformance test is a script annotated with the results it should have,
+// in the format of the Crafting Interpreters test suite:
+//
+//	print 1 + 2; // expect: 3
+//	print nil.x; // expect runtime error: Only instances have properties.
+//	var 1 = 2; // Error at 1: Expect variable name.
+//	// [line 9] Error at end: Expect '}' after block.
+//
+// An error is written as the interpreter reports it, and is on the line of
+// its comment unless the comment gives another.
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)`)
+	expectErrorAtLine  = regexp.MustCompile(`// \[line (\d+)\] ((Resolver )?Error.*)`)
+	expectError        = regexp.MustCompile(`// ((Resolver )?Error.*)`)
+
+	reportColumn = regexp.MustCompile(`^(\[line \d+):\d+`)
+)
+
+type conformanceTest struct {
+	path string
+	// The lines expected on stdout.
+	output []string
+	// The errors expected on stderr, each the first line of a report.
+	errors   []string
+	exitCode int
+}
+
+func parseConformanceTest(path string) (*conformanceTest, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	test := &conformanceTest{path: path}
+	for i, line := range strings.Split(string(source), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if m := expectOutput.FindStringSubmatch(line); m != nil {
+			test.output = append(test.output, m[1])
+		} else if m := expectRuntimeError.FindStringSubmatch(line); m != nil {
+			test.errors = append(test.errors, fmt.Sprintf("[line %v] Runtime Error: %v", i+1, m[1]))
+			test.exitCode = 70
+		} else if m := expectErrorAtLine.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			test.errors = append(test.errors, fmt.Sprintf("[line %v] %v", n, m[2]))
+			test.exitCode = 65
+		} else if m := expectError.FindStringSubmatch(line); m != nil {
+			test.errors = append(test.errors, fmt.Sprintf("[line %v] %v", i+1, m[1]))
+			test.exitCode = 65
+		}
+	}
+
+	return test, nil
+}
+
+// run runs the test in a new runner set up by configure, and describes each
+// way in which the results differ from those expected.
+func (test *conformanceTest) run(configure func(vm *lox.VM)) []string {
+	var stdout, stderr bytes.Buffer
+	r := newRunner(&stdout, &stderr)
+	configure(r.vm)
+
+	status, err := r.runFile(test.path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	// Only the first line of each report is compared, leaving out source
+	// snippets and tracebacks, and without the column, which annotations
+	// don't give.
+	var errors []string
+	for _, line := range splitLines(stderr.String()) {
+		if strings.HasPrefix(line, "[line ") {
+			errors = append(errors, reportColumn.ReplaceAllString(line, "$1"))
+		}
+	}
+
+	failures := compareLines("output", test.output, splitLines(stdout.String()))
+	failures = append(failures, compareLines("error", test.errors, errors)...)
+	if status.exitCode() != test.exitCode {
+		failures = append(failures, fmt.Sprintf("Expected exit code %v but got %v.", test.exitCode, status.exitCode()))
+	}
+
+	return failures
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func compareLines(kind string, expected, actual []string) []string {
+	var failures []string
+	for i := 0; i < max(len(expected), len(actual)); i++ {
+		switch {
+		case i >= len(actual):
+			failures = append(failures, fmt.Sprintf("Missing expected %v %q.", kind, expected[i]))
+		case i >= len(expected):
+			failures = append(failures, fmt.Sprintf("Unexpected %v %q.", kind, actual[i]))
+		case expected[i] != actual[i]:
+			failures = append(failures, fmt.Sprintf("Expected %v %q but got %q.", kind, expected[i], actual[i]))
+		}
+	}
+
+	return failures
+}
+
+// findConformanceTests returns the scripts in dir and its subdirectories.
+// Scripts whose names start with an underscore are modules imported by the
+// tests rather than tests themselves.
+func findConformanceTests(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".lox" && !strings.HasPrefix(d.Name(), "_") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+
+	return paths, err
+}
+
+// testDir runs the conformance tests in dir, reporting whether each passed,
+// and returns whether they all did.
+func (r *runner) testDir(dir string, configure func(vm *lox.VM)) (bool, error) {
+	paths, err := findConformanceTests(dir)
+	if err != nil {
+		return false, err
+	}
+
+	passed := 0
+	for _, path := range paths {
+		test, err := parseConformanceTest(path)
+		if err != nil {
+			return false, err
+		}
+
+		failures := test.run(configure)
+		if len(failures) == 0 {
+			passed += 1
+			fmt.Fprintf(r.stdout, "PASS %v\n", path)
+			continue
+		}
+
+		fmt.Fprintf(r.stdout, "FAIL %v\n", path)
+		for _, failure := range failures {
+			fmt.Fprintf(r.stdout, "     %v\n", failure)
+		}
+	}
+
+	fmt.Fprintf(r.stdout, "%v of %v tests passed.\n", passed, len(paths))
+	return passed == len(paths), nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/snocorp/golox/lox"
+)
+
+func TestConformance(t *testing.T) {
+	paths, err := findConformanceTests("testdata/conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backends := []struct {
+		name    string
+		backend lox.Backend
+	}{
+		{"tree", lox.TreeWalker},
+		{"vm", lox.Bytecode},
+	}
+
+	for _, path := range paths {
+		test, err := parseConformanceTest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, b := range backends {
+			backend := b.backend
+			t.Run(path+"/"+b.name, func(t *testing.T) {
+				failures := test.run(func(vm *lox.VM) {
+					vm.SetBackend(backend)
+				})
+				for _, failure := range failures {
+					t.Error(failure)
+				}
+			})
+		}
+	}
+}
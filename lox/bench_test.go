@@ -0,0 +1,79 @@
+package lox
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The programs in testdata/bench are each benchmarked on both backends, so
+// adding a program there adds a benchmark, such as BenchmarkPrograms/fib/vm.
+// BenchmarkPrograms/fib/tree and BenchmarkPrograms/loop/tree measure the
+// tree-walking interpreter on recursive calls and on loops over locals.
+const benchDir = "testdata/bench"
+
+// runPipeline scans, parses, resolves and executes source in a new
+// interpreter, discarding its output.
+func runPipeline(source string, backend Backend) error {
+	scanner := newScanner(source)
+	tokens, err := scanner.scanTokens()
+	if err != nil {
+		return err
+	}
+
+	statements, err := newParser[any](tokens).parse()
+	if err != nil {
+		return err
+	}
+
+	inter := newInterpreter(io.Discard)
+	err = newResolver(inter).resolve(statements)
+	if err != nil {
+		return err
+	}
+
+	if backend == Bytecode {
+		return newMachine(inter).interpret(statements)
+	}
+	return inter.interpret(statements)
+}
+
+func benchmarkPipeline(b *testing.B, source string, backend Backend) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := runPipeline(source, backend)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPrograms(b *testing.B) {
+	paths, err := filepath.Glob(filepath.Join(benchDir, "*.lox"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(paths) == 0 {
+		b.Fatalf("no programs in %v", benchDir)
+	}
+
+	for _, path := range paths {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		source := string(bytes)
+
+		name := strings.TrimSuffix(filepath.Base(path), ".lox")
+		b.Run(name, func(b *testing.B) {
+			b.Run("tree", func(b *testing.B) {
+				benchmarkPipeline(b, source, TreeWalker)
+			})
+			b.Run("vm", func(b *testing.B) {
+				benchmarkPipeline(b, source, Bytecode)
+			})
+		})
+	}
+}
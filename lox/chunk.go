@@ -0,0 +1,93 @@
+package lox
+
+import "fmt"
+
+// The instructions of the bytecode virtual machine. Operands follow the
+// opcode: constant indexes and jump offsets are two bytes, big-endian, while
+// local slots, upvalue indexes and argument counts are one byte.
+const (
+	OP_CONSTANT      = iota // constant
+	OP_NIL           = iota
+	OP_TRUE          = iota
+	OP_FALSE         = iota
+	OP_POP           = iota
+	OP_GET_LOCAL     = iota // slot
+	OP_SET_LOCAL     = iota // slot
+	OP_GET_GLOBAL    = iota // name constant
+	OP_DEFINE_GLOBAL = iota // name constant
+	OP_SET_GLOBAL    = iota // name constant
+	OP_GET_UPVALUE   = iota // upvalue
+	OP_SET_UPVALUE   = iota // upvalue
+	OP_GET_PROPERTY  = iota // name constant
+	OP_CHECK_FIELDS  = iota
+	OP_SET_PROPERTY  = iota // name constant
+	OP_GET_SUPER     = iota // name constant
+	OP_GET_INDEX     = iota
+	OP_SET_INDEX     = iota
+	OP_EQUAL         = iota
+	OP_NOT_EQUAL     = iota
+	OP_GREATER       = iota
+	OP_GREATER_EQUAL = iota
+	OP_LESS          = iota
+	OP_LESS_EQUAL    = iota
+	OP_ADD           = iota
+	OP_SUBTRACT      = iota
+	OP_MULTIPLY      = iota
+	OP_DIVIDE        = iota
+	OP_INT_DIVIDE    = iota
+	OP_MODULO        = iota
+	OP_POWER         = iota
+	OP_NOT           = iota
+	OP_NEGATE        = iota
+	OP_PRINT         = iota
+	OP_JUMP          = iota // offset
+	OP_JUMP_IF_FALSE = iota // offset
+	OP_LOOP          = iota // offset
+	OP_CALL          = iota // argument count
+	OP_INVOKE        = iota // name constant, argument count
+	OP_CLOSURE       = iota // function constant, then a local flag and index per upvalue
+	OP_CLOSE_UPVALUE = iota
+	OP_RETURN        = iota
+	OP_CLASS         = iota // name constant
+	OP_INHERIT       = iota
+	OP_METHOD        = iota // name constant
+	OP_LIST          = iota // element count (two bytes)
+	OP_MAP           = iota // entry count (two bytes)
+	OP_ITER          = iota
+	OP_FOR_NEXT      = iota // offset
+	OP_THROW         = iota
+	OP_TRY_CATCH     = iota // offset
+	OP_TRY_FINALLY   = iota // offset
+	OP_POP_HANDLER   = iota
+	OP_RETHROW       = iota
+	OP_IMPORT        = iota // import statement constant
+)
+
+// chunk is the compiled code of a function. Each byte of code has the token
+// it was compiled from, which locates runtime errors.
+type chunk struct {
+	code      []byte
+	tokens    []*token
+	constants []any
+}
+
+func (c *chunk) write(b byte, t *token) {
+	c.code = append(c.code, b)
+	c.tokens = append(c.tokens, t)
+}
+
+// function is a compiled function, from which closures are created at run
+// time.
+type function struct {
+	name         string
+	arity        int
+	upvalueCount int
+	chunk        chunk
+}
+
+func (f *function) String() string {
+	if f.name == "" {
+		return "<script>"
+	}
+	return fmt.Sprintf("<fn %v>", f.name)
+}
@@ -1,14 +1,22 @@
-package main
+package lox
 
 import "fmt"
 
+// classMethod is a method declared in a class body, which is bound to an
+// instance when it is accessed. It is a *LoxFunction, or a *closure when the
+// class was compiled to bytecode.
+type classMethod interface {
+	LoxCallable
+	bind(instance *LoxInstance) (LoxCallable, error)
+}
+
 type LoxClass struct {
 	name       string
 	superclass *LoxClass
-	methods    map[string]*LoxFunction
+	methods    map[string]classMethod
 }
 
-func newLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+func newLoxClass(name string, superclass *LoxClass, methods map[string]classMethod) *LoxClass {
 	return &LoxClass{name: name, superclass: superclass, methods: methods}
 }
 
@@ -37,7 +45,7 @@ func (c *LoxClass) call(v *interpreter, arguments []any) (any, error) {
 	return instance, nil
 }
 
-func (c LoxClass) findMethod(name string) *LoxFunction {
+func (c LoxClass) findMethod(name string) classMethod {
 	m, ok := c.methods[name]
 	if ok {
 		return m
@@ -83,7 +91,7 @@ func (i *LoxInstance) get(name *token) (any, error) {
 		return method, nil
 	}
 
-	return nil, &RuntimeError{t: name, message: fmt.Sprintf("Undefined property '%v'", name.lexeme)}
+	return nil, &RuntimeError{t: name, message: fmt.Sprintf("Undefined property '%v'.", name.lexeme)}
 }
 
 func (i *LoxInstance) set(name *token, value any) {
@@ -0,0 +1,7 @@
+package lox
+
+import "time"
+
+func clock(arguments []Value) (Value, error) {
+	return float64(time.Now().UnixMilli()), nil
+}
@@ -0,0 +1,1104 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+// CompileError reports a program that parsed and resolved but exceeds a
+// limit of the bytecode, such as the number of locals in a function.
+type CompileError struct {
+	t       *token
+	message string
+}
+
+func (err *CompileError) Error() string {
+	return fmt.Sprintf("[%v] Error at %v: %v%v", err.t.position(), err.t.lexeme, err.message, err.t.snippet())
+}
+
+type local struct {
+	name     string
+	depth    int
+	captured bool
+}
+
+type upvalueRef struct {
+	index   byte
+	isLocal bool
+}
+
+// loop records the jumps out of a loop body that are patched once the loop
+// is compiled.
+type loop struct {
+	// The locals and handlers in scope outside the body.
+	locals   int
+	handlers int
+	// The target of a continue, or -1 while it is not yet known.
+	start     int
+	breaks    []int
+	continues []int
+}
+
+// tryHandler is an exception handler installed by the try statement being
+// compiled. Leaving the statement by break, continue or return removes the
+// handler and runs its finally block, if it has one.
+type tryHandler struct {
+	finally *Block[any]
+}
+
+// compiler compiles the body of one function to bytecode. Local variables
+// live in stack slots numbered from the start of the call frame, and
+// variables captured from enclosing functions are reached through upvalues.
+type compiler struct {
+	enclosing  *compiler
+	function   *function
+	funcType   int
+	locals     []local
+	upvalues   []upvalueRef
+	scopeDepth int
+	loops      []*loop
+	handlers   []tryHandler
+	strings    map[string]int
+	// The last token compiled, which locates errors in code without one.
+	last *token
+}
+
+func newCompiler(enclosing *compiler, funcType int, name string) *compiler {
+	c := &compiler{
+		enclosing: enclosing,
+		function:  &function{name: name},
+		funcType:  funcType,
+		strings:   map[string]int{},
+	}
+
+	// Slot zero holds the receiver of a method and is otherwise unnamed.
+	receiver := ""
+	if funcType == FUNC_TYPE_METHOD || funcType == FUNC_TYPE_INIT {
+		receiver = "this"
+	}
+	c.locals = append(c.locals, local{name: receiver})
+
+	return c
+}
+
+// compile compiles a resolved program to the function that runs it.
+func compile(statements []Stmt[any]) (*function, error) {
+	c := newCompiler(nil, FUNC_TYPE_NONE, "")
+	for _, s := range statements {
+		err := s.accept(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.emitReturn(nil)
+
+	return c.function, nil
+}
+
+func (c *compiler) chunk() *chunk {
+	return &c.function.chunk
+}
+
+func (c *compiler) emit(t *token, bytes ...byte) {
+	if t != nil {
+		c.last = t
+	}
+	for _, b := range bytes {
+		c.chunk().write(b, t)
+	}
+}
+
+func (c *compiler) error(t *token, message string) error {
+	if t == nil {
+		t = c.last
+	}
+	return &CompileError{t: t, message: message}
+}
+
+func (c *compiler) emitShort(t *token, op byte, operand int) {
+	c.emit(t, op, byte(operand>>8), byte(operand))
+}
+
+func (c *compiler) emitReturn(t *token) {
+	if c.funcType == FUNC_TYPE_INIT {
+		c.emit(t, OP_GET_LOCAL, 0)
+	} else {
+		c.emit(t, OP_NIL)
+	}
+	c.emit(t, OP_RETURN)
+}
+
+func (c *compiler) makeConstant(t *token, value any) (int, error) {
+	s, ok := value.(string)
+	if ok {
+		index, ok := c.strings[s]
+		if ok {
+			return index, nil
+		}
+	}
+
+	constants := &c.chunk().constants
+	if len(*constants) > math.MaxUint16 {
+		return 0, c.error(t, "Too many constants in one chunk.")
+	}
+	*constants = append(*constants, value)
+
+	index := len(*constants) - 1
+	if ok {
+		c.strings[s] = index
+	}
+	return index, nil
+}
+
+// emitConstant emits an instruction whose operand is a constant.
+func (c *compiler) emitConstant(t *token, op byte, value any) error {
+	index, err := c.makeConstant(t, value)
+	if err != nil {
+		return err
+	}
+
+	c.emitShort(t, op, index)
+	return nil
+}
+
+// emitJump emits a forward jump and returns the offset of its operand, to be
+// patched once the target is known.
+func (c *compiler) emitJump(t *token, op byte) int {
+	c.emitShort(t, op, 0xffff)
+	return len(c.chunk().code) - 2
+}
+
+func (c *compiler) patchJump(t *token, offset int) error {
+	jump := len(c.chunk().code) - offset - 2
+	if jump > math.MaxUint16 {
+		return c.error(t, "Too much code to jump over.")
+	}
+
+	c.chunk().code[offset] = byte(jump >> 8)
+	c.chunk().code[offset+1] = byte(jump)
+	return nil
+}
+
+func (c *compiler) emitLoop(t *token, start int) error {
+	offset := len(c.chunk().code) - start + 3
+	if offset > math.MaxUint16 {
+		return c.error(t, "Loop body too large.")
+	}
+
+	c.emitShort(t, OP_LOOP, offset)
+	return nil
+}
+
+func (c *compiler) beginScope() {
+	c.scopeDepth += 1
+}
+
+func (c *compiler) endScope(t *token) {
+	c.scopeDepth -= 1
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		c.popLocal(t, c.locals[len(c.locals)-1])
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+func (c *compiler) popLocal(t *token, l local) {
+	if l.captured {
+		c.emit(t, OP_CLOSE_UPVALUE)
+	} else {
+		c.emit(t, OP_POP)
+	}
+}
+
+func (c *compiler) addLocal(name *token) error {
+	if len(c.locals) > math.MaxUint8 {
+		return c.error(name, "Too many local variables in function.")
+	}
+
+	c.locals = append(c.locals, local{name: name.lexeme, depth: c.scopeDepth})
+	return nil
+}
+
+// addHidden adds a local that holds an intermediate value, such as the
+// iterator of a for-in loop, and can't be named by the program.
+func (c *compiler) addHidden(t *token) error {
+	return c.addLocal(&token{tokenType: IDENTIFIER, lexeme: "", line: t.line, source: t.source, offset: t.offset, length: t.length})
+}
+
+func (c *compiler) resolveLocal(name string) int {
+	for i := len(c.locals) - 1; i >= 0; i-- {
+		if c.locals[i].name == name && name != "" {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (c *compiler) resolveUpvalue(name *token) (int, error) {
+	if c.enclosing == nil {
+		return -1, nil
+	}
+
+	i := c.enclosing.resolveLocal(name.lexeme)
+	if i >= 0 {
+		c.enclosing.locals[i].captured = true
+		return c.addUpvalue(name, byte(i), true)
+	}
+
+	i, err := c.enclosing.resolveUpvalue(name)
+	if err != nil || i < 0 {
+		return i, err
+	}
+
+	return c.addUpvalue(name, byte(i), false)
+}
+
+func (c *compiler) addUpvalue(name *token, index byte, isLocal bool) (int, error) {
+	for i, upvalue := range c.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i, nil
+		}
+	}
+
+	if len(c.upvalues) > math.MaxUint8 {
+		return 0, c.error(name, "Too many closure variables in function.")
+	}
+
+	c.upvalues = append(c.upvalues, upvalueRef{index: index, isLocal: isLocal})
+	return len(c.upvalues) - 1, nil
+}
+
+// variable emits a read of name, or a write of the value on top of the stack
+// if set is true.
+func (c *compiler) variable(name *token, set bool) error {
+	getOp, setOp := byte(OP_GET_LOCAL), byte(OP_SET_LOCAL)
+	i := c.resolveLocal(name.lexeme)
+	if i < 0 {
+		var err error
+		i, err = c.resolveUpvalue(name)
+		if err != nil {
+			return err
+		}
+		getOp, setOp = OP_GET_UPVALUE, OP_SET_UPVALUE
+	}
+
+	if i < 0 {
+		if set {
+			return c.emitConstant(name, OP_SET_GLOBAL, name.lexeme)
+		}
+		return c.emitConstant(name, OP_GET_GLOBAL, name.lexeme)
+	}
+
+	if set {
+		c.emit(name, setOp, byte(i))
+	} else {
+		c.emit(name, getOp, byte(i))
+	}
+	return nil
+}
+
+// declare makes the value on top of the stack the variable name, which is a
+// local unless it is declared at the top level.
+func (c *compiler) declare(name *token) error {
+	if c.scopeDepth > 0 {
+		return c.addLocal(name)
+	}
+
+	return c.emitConstant(name, OP_DEFINE_GLOBAL, name.lexeme)
+}
+
+// exit leaves the handlers above depth, running their finally blocks, before
+// a jump or return out of a try statement.
+func (c *compiler) exit(t *token, depth int) error {
+	handlers := c.handlers
+	defer func() {
+		c.handlers = handlers
+	}()
+
+	for i := len(handlers) - 1; i >= depth; i-- {
+		c.emit(t, OP_POP_HANDLER)
+		if handlers[i].finally != nil {
+			c.handlers = handlers[:i]
+			err := handlers[i].finally.accept(c)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *compiler) compileFunction(decl *Function[any], funcType int) error {
+	name := "anonymous"
+	if decl.name != nil {
+		name = decl.name.lexeme
+	}
+
+	fc := newCompiler(c, funcType, name)
+	fc.function.arity = len(decl.params)
+	fc.beginScope()
+	for _, param := range decl.params {
+		err := fc.addLocal(param)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range decl.body {
+		err := s.accept(fc)
+		if err != nil {
+			return err
+		}
+	}
+	fc.emitReturn(nil)
+	fc.function.upvalueCount = len(fc.upvalues)
+
+	t := decl.name
+	if t == nil && len(decl.params) > 0 {
+		t = decl.params[0]
+	}
+	err := c.emitConstant(t, OP_CLOSURE, fc.function)
+	if err != nil {
+		return err
+	}
+	for _, upvalue := range fc.upvalues {
+		isLocal := byte(0)
+		if upvalue.isLocal {
+			isLocal = 1
+		}
+		c.emit(t, isLocal, upvalue.index)
+	}
+
+	return nil
+}
+
+func (c *compiler) expression(e Expr[any]) error {
+	_, err := e.accept(c)
+	return err
+}
+
+func (c *compiler) visitAssignExpr(e *Assign[any]) (any, error) {
+	err := c.expression(e.value)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.variable(e.name, true)
+}
+
+func (c *compiler) visitBinaryExpr(e *Binary[any]) (any, error) {
+	err := c.expression(e.left)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.expression(e.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.operator.tokenType {
+	case BANG_EQUAL:
+		c.emit(e.operator, OP_NOT_EQUAL)
+	case EQUAL_EQUAL:
+		c.emit(e.operator, OP_EQUAL)
+	case GREATER:
+		c.emit(e.operator, OP_GREATER)
+	case GREATER_EQUAL:
+		c.emit(e.operator, OP_GREATER_EQUAL)
+	case LESS:
+		c.emit(e.operator, OP_LESS)
+	case LESS_EQUAL:
+		c.emit(e.operator, OP_LESS_EQUAL)
+	case PLUS:
+		c.emit(e.operator, OP_ADD)
+	case MINUS:
+		c.emit(e.operator, OP_SUBTRACT)
+	case STAR:
+		c.emit(e.operator, OP_MULTIPLY)
+	case SLASH:
+		c.emit(e.operator, OP_DIVIDE)
+	case TILDE_SLASH:
+		c.emit(e.operator, OP_INT_DIVIDE)
+	case PERCENT:
+		c.emit(e.operator, OP_MODULO)
+	case STAR_STAR:
+		c.emit(e.operator, OP_POWER)
+	}
+
+	return nil, nil
+}
+
+func (c *compiler) visitCallExpr(e *Call[any]) (any, error) {
+	// A method call is compiled to a single invoke, which avoids binding the
+	// method to the receiver.
+	get, isGet := e.callee.(*Get[any])
+	var err error
+	if isGet {
+		err = c.expression(get.object)
+	} else {
+		err = c.expression(e.callee)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, argument := range e.arguments {
+		err = c.expression(argument)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if isGet {
+		index, err := c.makeConstant(get.name, get.name.lexeme)
+		if err != nil {
+			return nil, err
+		}
+		c.emit(e.paren, OP_INVOKE)
+		c.emit(get.name, byte(index>>8), byte(index))
+		c.emit(e.paren, byte(len(e.arguments)))
+	} else {
+		c.emit(e.paren, OP_CALL, byte(len(e.arguments)))
+	}
+
+	return nil, nil
+}
+
+func (c *compiler) visitGetExpr(e *Get[any]) (any, error) {
+	err := c.expression(e.object)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.emitConstant(e.name, OP_GET_PROPERTY, e.name.lexeme)
+}
+
+func (c *compiler) visitGroupingExpr(e *Grouping[any]) (any, error) {
+	return nil, c.expression(e.expression)
+}
+
+func (c *compiler) visitIndexExpr(e *Index[any]) (any, error) {
+	err := c.expression(e.object)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.expression(e.index)
+	if err != nil {
+		return nil, err
+	}
+
+	c.emit(e.bracket, OP_GET_INDEX)
+	return nil, nil
+}
+
+func (c *compiler) visitIndexSetExpr(e *IndexSet[any]) (any, error) {
+	for _, operand := range []Expr[any]{e.object, e.index, e.value} {
+		err := c.expression(operand)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.emit(e.bracket, OP_SET_INDEX)
+	return nil, nil
+}
+
+func (c *compiler) visitLambdaExpr(e *Lambda[any]) (any, error) {
+	return nil, c.compileFunction(e.function, FUNC_TYPE_FUNCTION)
+}
+
+func (c *compiler) visitListExpr(e *List[any]) (any, error) {
+	if len(e.elements) > math.MaxUint16 {
+		return nil, c.error(e.bracket, "Too many elements in a list literal.")
+	}
+
+	for _, element := range e.elements {
+		err := c.expression(element)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.emitShort(e.bracket, OP_LIST, len(e.elements))
+	return nil, nil
+}
+
+func (c *compiler) visitLiteralExpr(e *Literal[any]) (any, error) {
+	switch e.value {
+	case nil:
+		c.emit(nil, OP_NIL)
+	case true:
+		c.emit(nil, OP_TRUE)
+	case false:
+		c.emit(nil, OP_FALSE)
+	default:
+		return nil, c.emitConstant(nil, OP_CONSTANT, e.value)
+	}
+
+	return nil, nil
+}
+
+func (c *compiler) visitLogicalExpr(e *Logical[any]) (any, error) {
+	err := c.expression(e.left)
+	if err != nil {
+		return nil, err
+	}
+
+	var end int
+	if e.operator.tokenType == OR {
+		elseJump := c.emitJump(e.operator, OP_JUMP_IF_FALSE)
+		end = c.emitJump(e.operator, OP_JUMP)
+		err = c.patchJump(e.operator, elseJump)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		end = c.emitJump(e.operator, OP_JUMP_IF_FALSE)
+	}
+
+	c.emit(e.operator, OP_POP)
+	err = c.expression(e.right)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.patchJump(e.operator, end)
+}
+
+func (c *compiler) visitMapExpr(e *Map[any]) (any, error) {
+	if len(e.keys) > math.MaxUint16 {
+		return nil, c.error(e.brace, "Too many entries in a map literal.")
+	}
+
+	for i, key := range e.keys {
+		err := c.expression(key)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.expression(e.values[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.emitShort(e.brace, OP_MAP, len(e.keys))
+	return nil, nil
+}
+
+func (c *compiler) visitSetExpr(e *Set[any]) (any, error) {
+	err := c.expression(e.object)
+	if err != nil {
+		return nil, err
+	}
+	c.emit(e.name, OP_CHECK_FIELDS)
+
+	err = c.expression(e.value)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.emitConstant(e.name, OP_SET_PROPERTY, e.name.lexeme)
+}
+
+func (c *compiler) visitSuperExpr(e *Super[any]) (any, error) {
+	err := c.variable(&token{tokenType: THIS, lexeme: "this", line: e.keyword.line}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.variable(e.keyword, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.emitConstant(e.method, OP_GET_SUPER, e.method.lexeme)
+}
+
+func (c *compiler) visitThisExpr(e *This[any]) (any, error) {
+	return nil, c.variable(e.keyword, false)
+}
+
+func (c *compiler) visitUnaryExpr(e *Unary[any]) (any, error) {
+	err := c.expression(e.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.operator.tokenType {
+	case BANG:
+		c.emit(e.operator, OP_NOT)
+	case MINUS:
+		c.emit(e.operator, OP_NEGATE)
+	}
+
+	return nil, nil
+}
+
+func (c *compiler) visitVariableExpr(e *Variable[any]) (any, error) {
+	return nil, c.variable(e.name, false)
+}
+
+func (c *compiler) visitBlockStmt(s *Block[any]) error {
+	c.beginScope()
+	for _, statement := range s.statements {
+		err := statement.accept(c)
+		if err != nil {
+			return err
+		}
+	}
+	c.endScope(nil)
+
+	return nil
+}
+
+// jumpOut pops the locals and handlers of the innermost loop's body and
+// emits a jump to be patched with the loop's break or continue target.
+func (c *compiler) jumpOut(keyword *token) (int, error) {
+	l := c.loops[len(c.loops)-1]
+	err := c.exit(keyword, l.handlers)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(c.locals) - 1; i >= l.locals; i-- {
+		c.popLocal(keyword, c.locals[i])
+	}
+
+	return c.emitJump(keyword, OP_JUMP), nil
+}
+
+func (c *compiler) visitBreakStmt(s *Break[any]) error {
+	jump, err := c.jumpOut(s.keyword)
+	if err != nil {
+		return err
+	}
+
+	l := c.loops[len(c.loops)-1]
+	l.breaks = append(l.breaks, jump)
+	return nil
+}
+
+func (c *compiler) visitContinueStmt(s *Continue[any]) error {
+	l := c.loops[len(c.loops)-1]
+	if l.start < 0 {
+		jump, err := c.jumpOut(s.keyword)
+		if err != nil {
+			return err
+		}
+		l.continues = append(l.continues, jump)
+		return nil
+	}
+
+	err := c.exit(s.keyword, l.handlers)
+	if err != nil {
+		return err
+	}
+	for i := len(c.locals) - 1; i >= l.locals; i-- {
+		c.popLocal(s.keyword, c.locals[i])
+	}
+
+	return c.emitLoop(s.keyword, l.start)
+}
+
+func (c *compiler) visitClassStmt(s *Class[any]) error {
+	err := c.emitConstant(s.name, OP_CLASS, s.name.lexeme)
+	if err != nil {
+		return err
+	}
+
+	err = c.declare(s.name)
+	if err != nil {
+		return err
+	}
+
+	if s.superclass != nil {
+		c.beginScope()
+		err = c.variable(s.superclass.name, false)
+		if err != nil {
+			return err
+		}
+		err = c.addLocal(&token{tokenType: SUPER, lexeme: "super", line: s.superclass.name.line})
+		if err != nil {
+			return err
+		}
+
+		err = c.variable(s.name, false)
+		if err != nil {
+			return err
+		}
+		c.emit(s.superclass.name, OP_INHERIT)
+	}
+
+	err = c.variable(s.name, false)
+	if err != nil {
+		return err
+	}
+
+	for _, method := range s.methods {
+		funcType := FUNC_TYPE_METHOD
+		if method.name.lexeme == "init" {
+			funcType = FUNC_TYPE_INIT
+		}
+
+		err = c.compileFunction(method, funcType)
+		if err != nil {
+			return err
+		}
+
+		err = c.emitConstant(method.name, OP_METHOD, method.name.lexeme)
+		if err != nil {
+			return err
+		}
+	}
+	c.emit(s.name, OP_POP)
+
+	if s.superclass != nil {
+		c.endScope(s.name)
+	}
+
+	return nil
+}
+
+func (c *compiler) visitExpressionStmt(s *Expression[any]) error {
+	err := c.expression(s.expression)
+	if err != nil {
+		return err
+	}
+
+	c.emit(nil, OP_POP)
+	return nil
+}
+
+func (c *compiler) visitForInStmt(s *ForIn[any]) error {
+	c.beginScope()
+	err := c.expression(s.iterable)
+	if err != nil {
+		return err
+	}
+	c.emit(s.keyword, OP_ITER)
+	err = c.addHidden(s.keyword)
+	if err != nil {
+		return err
+	}
+
+	start := len(c.chunk().code)
+	exit := c.emitJump(s.keyword, OP_FOR_NEXT)
+
+	l := &loop{locals: len(c.locals), handlers: len(c.handlers), start: start}
+	c.loops = append(c.loops, l)
+
+	// Each iteration has a variable of its own for closures to capture.
+	c.beginScope()
+	err = c.addLocal(s.name)
+	if err != nil {
+		return err
+	}
+	err = s.body.accept(c)
+	if err != nil {
+		return err
+	}
+	c.endScope(s.keyword)
+
+	err = c.emitLoop(s.keyword, start)
+	if err != nil {
+		return err
+	}
+
+	c.loops = c.loops[:len(c.loops)-1]
+	err = c.patchJump(s.keyword, exit)
+	if err != nil {
+		return err
+	}
+	for _, jump := range l.breaks {
+		err = c.patchJump(s.keyword, jump)
+		if err != nil {
+			return err
+		}
+	}
+	c.endScope(s.keyword)
+
+	return nil
+}
+
+func (c *compiler) visitFunctionStmt(s *Function[any]) error {
+	if c.scopeDepth > 0 {
+		// The function is in scope in its own body, so that it can recurse.
+		err := c.addLocal(s.name)
+		if err != nil {
+			return err
+		}
+		return c.compileFunction(s, FUNC_TYPE_FUNCTION)
+	}
+
+	err := c.compileFunction(s, FUNC_TYPE_FUNCTION)
+	if err != nil {
+		return err
+	}
+
+	return c.emitConstant(s.name, OP_DEFINE_GLOBAL, s.name.lexeme)
+}
+
+func (c *compiler) visitIfStmt(s *If[any]) error {
+	err := c.expression(s.condition)
+	if err != nil {
+		return err
+	}
+
+	thenJump := c.emitJump(nil, OP_JUMP_IF_FALSE)
+	c.emit(nil, OP_POP)
+	err = s.thenBranch.accept(c)
+	if err != nil {
+		return err
+	}
+
+	elseJump := c.emitJump(nil, OP_JUMP)
+	err = c.patchJump(nil, thenJump)
+	if err != nil {
+		return err
+	}
+	c.emit(nil, OP_POP)
+
+	if s.elseBranch != nil {
+		err = s.elseBranch.accept(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.patchJump(nil, elseJump)
+}
+
+func (c *compiler) visitImportStmt(s *Import[any]) error {
+	return c.emitConstant(s.path, OP_IMPORT, s)
+}
+
+func (c *compiler) visitPrintStmt(s *Print[any]) error {
+	err := c.expression(s.expression)
+	if err != nil {
+		return err
+	}
+
+	c.emit(nil, OP_PRINT)
+	return nil
+}
+
+func (c *compiler) visitReturnStmt(s *Return[any]) error {
+	if c.funcType == FUNC_TYPE_INIT {
+		err := c.exit(s.keyword, 0)
+		if err != nil {
+			return err
+		}
+		c.emitReturn(s.keyword)
+		return nil
+	}
+
+	if s.value != nil {
+		err := c.expression(s.value)
+		if err != nil {
+			return err
+		}
+	} else {
+		c.emit(s.keyword, OP_NIL)
+	}
+
+	if len(c.handlers) > 0 {
+		// The value is kept in a slot of its own while finally blocks run.
+		c.beginScope()
+		err := c.addHidden(s.keyword)
+		if err != nil {
+			return err
+		}
+
+		err = c.exit(s.keyword, 0)
+		if err != nil {
+			return err
+		}
+
+		c.locals = c.locals[:len(c.locals)-1]
+		c.scopeDepth -= 1
+	}
+
+	c.emit(s.keyword, OP_RETURN)
+	return nil
+}
+
+func (c *compiler) visitThrowStmt(s *Throw[any]) error {
+	err := c.expression(s.value)
+	if err != nil {
+		return err
+	}
+
+	c.emit(s.keyword, OP_THROW)
+	return nil
+}
+
+// visitTryStmt installs a handler for the finally block, if there is one,
+// outside the handler for the catch block:
+//
+//	TRY_FINALLY -> rethrow
+//	TRY_CATCH -> catch
+//	  try block
+//	POP_HANDLER
+//	JUMP -> finally
+//	catch: catch block with the caught value as its variable
+//	finally: POP_HANDLER
+//	  finally block
+//	JUMP -> end
+//	rethrow: finally block with the error in a hidden slot
+//	RETHROW
+//	end:
+func (c *compiler) visitTryStmt(s *Try[any]) error {
+	var finallyHandler, catchHandler int
+	if s.finallyBranch != nil {
+		finallyHandler = c.emitJump(nil, OP_TRY_FINALLY)
+		c.handlers = append(c.handlers, tryHandler{finally: s.finallyBranch})
+	}
+	if s.catchBranch != nil {
+		catchHandler = c.emitJump(s.catchName, OP_TRY_CATCH)
+		c.handlers = append(c.handlers, tryHandler{})
+	}
+
+	err := s.tryBranch.accept(c)
+	if err != nil {
+		return err
+	}
+
+	if s.catchBranch != nil {
+		c.emit(nil, OP_POP_HANDLER)
+		c.handlers = c.handlers[:len(c.handlers)-1]
+		skip := c.emitJump(nil, OP_JUMP)
+
+		err = c.patchJump(s.catchName, catchHandler)
+		if err != nil {
+			return err
+		}
+
+		c.beginScope()
+		err = c.addLocal(s.catchName)
+		if err != nil {
+			return err
+		}
+		for _, statement := range s.catchBranch.statements {
+			err = statement.accept(c)
+			if err != nil {
+				return err
+			}
+		}
+		c.endScope(nil)
+
+		err = c.patchJump(nil, skip)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.finallyBranch != nil {
+		c.emit(nil, OP_POP_HANDLER)
+		c.handlers = c.handlers[:len(c.handlers)-1]
+		err = s.finallyBranch.accept(c)
+		if err != nil {
+			return err
+		}
+		end := c.emitJump(nil, OP_JUMP)
+
+		err = c.patchJump(nil, finallyHandler)
+		if err != nil {
+			return err
+		}
+
+		c.beginScope()
+		err = c.addHidden(&token{tokenType: FINALLY, lexeme: "finally"})
+		if err != nil {
+			return err
+		}
+		err = s.finallyBranch.accept(c)
+		if err != nil {
+			return err
+		}
+		c.emit(nil, OP_RETHROW)
+		c.locals = c.locals[:len(c.locals)-1]
+		c.scopeDepth -= 1
+
+		err = c.patchJump(nil, end)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *compiler) visitVarStmt(s *Var[any]) error {
+	if s.initializer != nil {
+		err := c.expression(s.initializer)
+		if err != nil {
+			return err
+		}
+	} else {
+		c.emit(s.name, OP_NIL)
+	}
+
+	return c.declare(s.name)
+}
+
+func (c *compiler) visitWhileStmt(s *While[any]) error {
+	start := len(c.chunk().code)
+	err := c.expression(s.condition)
+	if err != nil {
+		return err
+	}
+
+	exit := c.emitJump(nil, OP_JUMP_IF_FALSE)
+	c.emit(nil, OP_POP)
+
+	// A continue runs the increment, which is compiled after the body.
+	l := &loop{locals: len(c.locals), handlers: len(c.handlers), start: -1}
+	if s.increment == nil {
+		l.start = start
+	}
+	c.loops = append(c.loops, l)
+	err = s.body.accept(c)
+	if err != nil {
+		return err
+	}
+	c.loops = c.loops[:len(c.loops)-1]
+
+	for _, jump := range l.continues {
+		err = c.patchJump(nil, jump)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.increment != nil {
+		err = c.expression(s.increment)
+		if err != nil {
+			return err
+		}
+		c.emit(nil, OP_POP)
+	}
+
+	err = c.emitLoop(nil, start)
+	if err != nil {
+		return err
+	}
+
+	err = c.patchJump(nil, exit)
+	if err != nil {
+		return err
+	}
+	c.emit(nil, OP_POP)
+
+	for _, jump := range l.breaks {
+		err = c.patchJump(nil, jump)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,27 +1,48 @@
-package main
+package lox
 
 import "fmt"
 
+// Environment holds the variables of one scope. Globals are looked up by
+// name, while the variables of a local scope are stored in the order they
+// are declared, at the slot the resolver assigned them.
 type Environment struct {
 	enclosing *Environment
 	values    map[string]any
+	slots     []any
 }
 
 func newEnvironment(enclosing *Environment) *Environment {
-	return &Environment{values: map[string]any{}, enclosing: enclosing}
+	if enclosing == nil {
+		return &Environment{values: map[string]any{}}
+	}
+
+	return &Environment{enclosing: enclosing}
 }
 
 func (e *Environment) define(name *token, value any) error {
-	_, ok := e.values[name.lexeme]
-	if ok {
-		return &RuntimeError{t: name, message: "Variable already defined '" + name.lexeme + "'."}
+	if e.values == nil {
+		// The resolver rejects a local declared twice in one scope.
+		e.slots = append(e.slots, value)
+		return nil
 	}
 
+	// Globals may be redefined so that a REPL session can replace earlier
+	// declarations.
 	e.values[name.lexeme] = value
 
 	return nil
 }
 
+// redefine sets the value of name, which must be the variable defined most
+// recently in e.
+func (e *Environment) redefine(name *token, value any) {
+	if e.values == nil {
+		e.slots[len(e.slots)-1] = value
+	} else {
+		e.values[name.lexeme] = value
+	}
+}
+
 func (e *Environment) get(name *token) (any, error) {
 	value, ok := e.values[name.lexeme]
 	if !ok {
@@ -35,9 +56,8 @@ func (e *Environment) get(name *token) (any, error) {
 	return value, nil
 }
 
-func (e *Environment) getAt(distance int, name string) (any, bool) {
-	value, ok := e.ancestor(distance).values[name]
-	return value, ok
+func (e *Environment) getAt(distance int, slot int) any {
+	return e.ancestor(distance).slots[slot]
 }
 
 func (e *Environment) ancestor(distance int) *Environment {
@@ -64,10 +84,13 @@ func (e *Environment) assign(name *token, value any) error {
 	return nil
 }
 
-func (e *Environment) assignAt(distance int, name *token, value any) {
-	e.ancestor(distance).values[name.lexeme] = value
+func (e *Environment) assignAt(distance int, slot int, value any) {
+	e.ancestor(distance).slots[slot] = value
 }
 
 func (e *Environment) String() string {
+	if e.values == nil {
+		return fmt.Sprintf("%v", e.slots)
+	}
 	return fmt.Sprintf("%v", e.values)
 }
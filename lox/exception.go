@@ -0,0 +1,79 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LoxError is the value bound by a catch clause when a runtime error, rather
+// than a thrown value, is caught. Scripts can read its message, line and
+// stack, and rethrow it to report the original error.
+type LoxError struct {
+	err *RuntimeError
+}
+
+func (e *LoxError) GetProperty(name string) (Value, bool) {
+	switch name {
+	case "message":
+		return e.err.message, true
+	case "line":
+		return float64(e.err.t.line), true
+	case "stack":
+		entries := tracebackEntries(e.err.trace, e.err.t)
+		stack := make([]any, len(entries))
+		for i, entry := range entries {
+			stack[i] = entry
+		}
+		return newLoxList(stack), true
+	}
+
+	return nil, false
+}
+
+func (e *LoxError) SetProperty(name string, value Value) error {
+	return fmt.Errorf("Can't set properties on an error.")
+}
+
+func (e *LoxError) Method(name string) (Callable, bool) {
+	return nil, false
+}
+
+func (e *LoxError) String() string {
+	return fmt.Sprintf("<error %v>", e.err.message)
+}
+
+// caught returns the value a catch clause binds for err, or false if err is
+// not an exception, such as a return or break passing through the try block.
+func caught(err error) (any, bool) {
+	var te *ThrowError
+	if errors.As(err, &te) {
+		return te.value, true
+	}
+
+	var re *RuntimeError
+	if errors.As(err, &re) {
+		return &LoxError{err: re}, true
+	}
+
+	return nil, false
+}
+
+// uncaught turns a thrown value that reached the top of the program into a
+// runtime error at the throw statement.
+func uncaught(err error) error {
+	var te *ThrowError
+	if !errors.As(err, &te) {
+		return err
+	}
+
+	le, ok := te.value.(*LoxError)
+	if ok {
+		return le.err
+	}
+
+	return &RuntimeError{
+		t:       te.t,
+		message: fmt.Sprintf("Uncaught exception: %v.", formatValue(te.value, map[any]bool{})),
+		trace:   te.trace,
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package lox
 
 type Expr[T any] interface {
 	accept(v Visitor[T]) (T, error)
@@ -50,6 +50,45 @@ func (e *Grouping[T]) accept(v Visitor[T]) (T, error) {
 	return v.visitGroupingExpr(e)
 }
 
+type Index[T any] struct {
+	object Expr[T]
+	bracket *token
+	index Expr[T]
+}
+
+func (e *Index[T]) accept(v Visitor[T]) (T, error) {
+	return v.visitIndexExpr(e)
+}
+
+type IndexSet[T any] struct {
+	object Expr[T]
+	bracket *token
+	index Expr[T]
+	value Expr[T]
+}
+
+func (e *IndexSet[T]) accept(v Visitor[T]) (T, error) {
+	return v.visitIndexSetExpr(e)
+}
+
+type Lambda[T any] struct {
+	keyword *token
+	function *Function[T]
+}
+
+func (e *Lambda[T]) accept(v Visitor[T]) (T, error) {
+	return v.visitLambdaExpr(e)
+}
+
+type List[T any] struct {
+	bracket *token
+	elements []Expr[T]
+}
+
+func (e *List[T]) accept(v Visitor[T]) (T, error) {
+	return v.visitListExpr(e)
+}
+
 type Literal[T any] struct {
 	value any
 }
@@ -68,6 +107,16 @@ func (e *Logical[T]) accept(v Visitor[T]) (T, error) {
 	return v.visitLogicalExpr(e)
 }
 
+type Map[T any] struct {
+	brace *token
+	keys []Expr[T]
+	values []Expr[T]
+}
+
+func (e *Map[T]) accept(v Visitor[T]) (T, error) {
+	return v.visitMapExpr(e)
+}
+
 type Set[T any] struct {
 	object Expr[T]
 	name *token
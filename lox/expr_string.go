@@ -1,4 +1,4 @@
-package main
+package lox
 
 import "fmt"
 
@@ -18,6 +18,14 @@ func (e *Grouping[T]) String() string {
 	return fmt.Sprintf("{ %v }", e.expression)
 }
 
+func (e *Index[T]) String() string {
+	return fmt.Sprintf("%v[%v]", e.object, e.index)
+}
+
+func (e *List[T]) String() string {
+	return fmt.Sprintf("%v", e.elements)
+}
+
 func (e *Literal[T]) String() string {
 	return fmt.Sprintf("%v", e.value)
 }
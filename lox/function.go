@@ -1,4 +1,4 @@
-package main
+package lox
 
 import "fmt"
 
@@ -6,6 +6,8 @@ type LoxFunction struct {
 	declaration   *Function[any]
 	closure       *Environment
 	isInitializer bool
+	// The global scope of the module that declared the function.
+	globals *Environment
 }
 
 func (f *LoxFunction) arity() int {
@@ -13,6 +15,12 @@ func (f *LoxFunction) arity() int {
 }
 
 func (f *LoxFunction) call(v *interpreter, arguments []any) (r any, err error) {
+	previous := v.globals
+	v.globals = f.globals
+	defer func() {
+		v.globals = previous
+	}()
+
 	env := newEnvironment(f.closure)
 	for i, p := range f.declaration.params {
 		err = env.define(p, arguments[i])
@@ -26,31 +34,37 @@ func (f *LoxFunction) call(v *interpreter, arguments []any) (r any, err error) {
 		re, ok := err.(*ReturnError)
 		if ok {
 			if f.isInitializer {
-				value, _ := f.closure.getAt(0, "this")
-				return value, nil
+				return f.closure.getAt(0, 0), nil
 			}
 			return re.value, nil
 		}
+		return nil, err
 	}
 
 	if f.isInitializer {
-		value, _ := f.closure.getAt(0, "this")
-		return value, nil
+		return f.closure.getAt(0, 0), nil
 	}
 
-	return nil, err
+	return nil, nil
 }
 
-func (f *LoxFunction) bind(instance *LoxInstance) (*LoxFunction, error) {
+func (f *LoxFunction) bind(instance *LoxInstance) (LoxCallable, error) {
 	env := newEnvironment(f.closure)
 	err := env.define(&token{tokenType: THIS, lexeme: "this"}, instance)
 	if err != nil {
 		return nil, err
 	}
 
-	return &LoxFunction{declaration: f.declaration, closure: env, isInitializer: f.isInitializer}, nil
+	return &LoxFunction{declaration: f.declaration, closure: env, isInitializer: f.isInitializer, globals: f.globals}, nil
 }
 
 func (f LoxFunction) String() string {
-	return fmt.Sprintf("<fn %v>", f.declaration.name.lexeme)
+	return fmt.Sprintf("<fn %v>", f.name())
+}
+
+func (f *LoxFunction) name() string {
+	if f.declaration.name == nil {
+		return "anonymous"
+	}
+	return f.declaration.name.lexeme
 }
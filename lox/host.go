@@ -0,0 +1,149 @@
+package lox
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// HostObject is implemented by Go values that scripts can use like class
+// instances. A property access first looks for a property and then for a
+// method of the same name.
+type HostObject interface {
+	GetProperty(name string) (Value, bool)
+	SetProperty(name string, value Value) error
+	Method(name string) (Callable, bool)
+}
+
+// Callable is implemented by Go values that scripts can call. An arity of
+// Variadic accepts any number of arguments.
+type Callable interface {
+	Arity() int
+	Call(arguments []Value) (Value, error)
+}
+
+// NewFunction returns fn as a Callable value, for example to return from
+// HostObject.Method.
+func NewFunction(name string, arity int, fn NativeFunc) Callable {
+	return newNativeFunction(name, arity, fn)
+}
+
+func (f *nativeFunction) Arity() int {
+	return f.params
+}
+
+func (f *nativeFunction) Call(arguments []Value) (Value, error) {
+	return f.fn(arguments)
+}
+
+// hostStruct exposes the exported fields and methods of a Go struct.
+type hostStruct struct {
+	rv reflect.Value
+}
+
+// Wrap returns a HostObject exposing the exported fields and methods of a Go
+// struct or pointer to a struct. Scripts may name a member with its first
+// letter in lower case, so the field Name is readable as both obj.Name and
+// obj.name. Fields can only be assigned when value is a pointer. An error is
+// returned if value is not a struct or a non-nil pointer to one.
+func Wrap(value any) (HostObject, error) {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("cannot wrap nil")
+	}
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, fmt.Errorf("cannot wrap nil %v", rv.Type())
+	}
+	if reflect.Indirect(rv).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot wrap %v: not a struct or pointer to a struct", rv.Type())
+	}
+
+	return &hostStruct{rv: rv}, nil
+}
+
+func (h *hostStruct) GetProperty(name string) (Value, bool) {
+	field, ok := h.field(name)
+	if !ok {
+		return nil, false
+	}
+
+	return fromGo(field), true
+}
+
+func (h *hostStruct) SetProperty(name string, value Value) error {
+	field, ok := h.field(name)
+	if !ok {
+		return fmt.Errorf("Undefined property '%v'.", name)
+	}
+	if !field.CanSet() {
+		return fmt.Errorf("Property '%v' is read-only.", name)
+	}
+
+	rv, err := toGo(value, field.Type())
+	if err != nil {
+		return fmt.Errorf("Property '%v' %v", name, err)
+	}
+	field.Set(rv)
+
+	return nil
+}
+
+func (h *hostStruct) Method(name string) (Callable, bool) {
+	for _, n := range memberNames(name) {
+		method := h.rv.MethodByName(n)
+		if method.IsValid() {
+			return bindNativeOrError(name, method.Interface()), true
+		}
+	}
+
+	return nil, false
+}
+
+func (h *hostStruct) field(name string) (reflect.Value, bool) {
+	rv := reflect.Indirect(h.rv)
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	for _, n := range memberNames(name) {
+		f, ok := rv.Type().FieldByName(n)
+		if ok && f.IsExported() {
+			return rv.FieldByIndex(f.Index), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func (h *hostStruct) String() string {
+	return fmt.Sprintf("<object %v>", h.rv.Type())
+}
+
+// memberNames returns the Go identifiers a script name may refer to.
+func memberNames(name string) []string {
+	if name == "" || unicode.IsUpper(rune(name[0])) {
+		return []string{name}
+	}
+
+	return []string{name, strings.ToUpper(name[:1]) + name[1:]}
+}
+
+// isHostStruct reports whether rv should be wrapped when passed to a script,
+// which is true of structs and struct pointers that are not Lox values.
+func isHostStruct(rv reflect.Value) bool {
+	t := rv.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.PkgPath() == reflect.TypeOf(hostStruct{}).PkgPath() {
+		return false
+	}
+
+	switch rv.Interface().(type) {
+	case HostObject, Callable:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,133 @@
+package lox_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/snocorp/golox/lox"
+)
+
+// The types exposed to scripts are declared outside package lox, as they
+// would be by a host program.
+type account struct {
+	Owner   string
+	Balance float64
+	secret  string
+}
+
+func (a *account) Deposit(amount float64) float64 {
+	a.Balance += amount
+	return a.Balance
+}
+
+func (a *account) Split() (float64, float64, float64) {
+	return a.Balance, 0, 0
+}
+
+// hostOutput runs source in vm and returns what it printed.
+func hostOutput(t *testing.T, vm *lox.VM, source string) string {
+	t.Helper()
+	var out bytes.Buffer
+	vm.SetOutput(&out)
+	err := vm.Eval(source)
+	if err != nil {
+		t.Fatalf("Eval(%q) failed: %v", source, err)
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// hostError runs source in vm and returns the first line of the error it
+// should fail with.
+func hostError(t *testing.T, vm *lox.VM, source string) string {
+	t.Helper()
+	err := vm.Eval(source)
+	if err == nil {
+		t.Fatalf("Eval(%q) succeeded", source)
+	}
+	return strings.SplitN(err.Error(), "\n", 2)[0]
+}
+
+func TestWrap(t *testing.T) {
+	vm := lox.New()
+	acct := &account{Owner: "ada", Balance: 10, secret: "hidden"}
+	obj, err := lox.Wrap(acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.SetGlobal("acct", obj)
+
+	got := hostOutput(t, vm, `
+print acct.Owner;
+print acct.owner;
+print acct.deposit(5);
+acct.balance = 20;
+print acct;
+`)
+	if got != "ada\nada\n15\n<object *lox_test.account>" {
+		t.Errorf("got %q", got)
+	}
+	if acct.Balance != 20 {
+		t.Errorf("Balance = %v after assignment", acct.Balance)
+	}
+
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{`acct.secret;`, "[line 1:6] Runtime Error: Undefined property 'secret'."},
+		{`acct.balance = "lots";`, "[line 1:6] Runtime Error: Property 'balance' must be a number but got a string."},
+		{`acct.split();`, "[line 1:12] Runtime Error: cannot bind split: too many results"},
+	}
+	for _, test := range tests {
+		message := hostError(t, vm, test.source)
+		if message != test.message {
+			t.Errorf("%v: got %q, want %q", test.source, message, test.message)
+		}
+	}
+}
+
+func TestWrapValue(t *testing.T) {
+	vm := lox.New()
+	obj, err := lox.Wrap(account{Owner: "ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.SetGlobal("acct", obj)
+
+	message := hostError(t, vm, `acct.owner = "bob";`)
+	if message != "[line 1:6] Runtime Error: Property 'owner' is read-only." {
+		t.Errorf("got %q", message)
+	}
+}
+
+func TestWrapRejectsNonStructs(t *testing.T) {
+	for _, value := range []any{nil, 5, "text", (*account)(nil), []account{}} {
+		_, err := lox.Wrap(value)
+		if err == nil {
+			t.Errorf("Wrap(%#v) returned no error", value)
+		}
+	}
+}
+
+func TestHostStructArguments(t *testing.T) {
+	vm := lox.New()
+	err := vm.Register("open", func(owner string) *account {
+		return &account{Owner: owner}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vm.Register("describe", func(a *account) string {
+		return fmt.Sprintf("%v has %v", a.Owner, a.Balance)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := hostOutput(t, vm, `var a = open("ada"); a.deposit(3); print describe(a);`)
+	if got != "ada has 3" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,878 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+type RuntimeError struct {
+	t       *token
+	message string
+	trace   []StackFrame
+}
+
+func (err *RuntimeError) Error() string {
+	return fmt.Sprintf("%v[%v] Runtime Error: %s%v", err.Traceback(), err.t.position(), err.message, err.t.snippet())
+}
+
+type ReturnError struct {
+	value any
+}
+
+func (err *ReturnError) Error() string {
+	return fmt.Sprintf("Return: %s", err.value)
+}
+
+type BreakError struct{}
+
+func (err *BreakError) Error() string {
+	return "Break"
+}
+
+type ContinueError struct{}
+
+func (err *ContinueError) Error() string {
+	return "Continue"
+}
+
+// ThrowError carries a value thrown by a throw statement until it is caught.
+type ThrowError struct {
+	t     *token
+	value any
+	trace []StackFrame
+}
+
+func (err *ThrowError) Error() string {
+	return fmt.Sprintf("Throw: %v", stringify(err.value))
+}
+
+// binding locates a local variable, by the number of scopes out from the
+// one where it is used and its slot in that scope.
+type binding struct {
+	depth int
+	slot  int
+}
+
+type interpreter struct {
+	globals *Environment
+	env     *Environment
+	locals  map[Expr[any]]binding
+	stdout  io.Writer
+
+	// The calls to Lox functions and classes in progress.
+	frames []StackFrame
+
+	// The natives defined in the global scope of every module.
+	builtins map[string]any
+	// The modules that have been imported, by absolute path.
+	modules map[string]*module
+	// The files being executed, the innermost import last.
+	files []string
+	// The directories searched for a module not found next to its importer.
+	searchPath []string
+}
+
+func newInterpreter(stdout io.Writer) *interpreter {
+	v := &interpreter{
+		locals: map[Expr[any]]binding{},
+		stdout: stdout,
+		builtins: map[string]any{
+			"clock":        newNativeFunction("clock", 0, clock),
+			"fromCharCode": newNativeFunction("fromCharCode", Variadic, fromCharCode),
+			"Math":         newMath(),
+		},
+		modules: map[string]*module{},
+	}
+	v.globals = v.newGlobals()
+	v.env = v.globals
+
+	return v
+}
+
+// newGlobals returns a global scope containing only the builtins.
+func (v *interpreter) newGlobals() *Environment {
+	globals := newEnvironment(nil)
+	for name, value := range v.builtins {
+		globals.values[name] = value
+	}
+
+	return globals
+}
+
+func (v *interpreter) interpret(statements []Stmt[any]) error {
+	for _, s := range statements {
+		err := v.execute(s)
+		if err != nil {
+			return uncaught(err)
+		}
+	}
+
+	return nil
+}
+
+func (v *interpreter) visitAssignExpr(e *Assign[any]) (any, error) {
+	value, err := v.evaluate(e.value)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := v.locals[e]
+	if ok {
+		v.env.assignAt(b.depth, b.slot, value)
+	} else {
+		err = v.globals.assign(e.name, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+func (v *interpreter) visitBinaryExpr(e *Binary[any]) (any, error) {
+	left, err := v.evaluate(e.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := v.evaluate(e.right)
+	if err != nil {
+		return nil, err
+	}
+
+	return binary(e.operator, left, right)
+}
+
+// binary applies a binary operator to its evaluated operands.
+func binary(operator *token, left, right any) (any, error) {
+	switch operator.tokenType {
+	case GREATER, GREATER_EQUAL, LESS, LESS_EQUAL, MINUS, SLASH, TILDE_SLASH, STAR, STAR_STAR, PERCENT:
+		leftValue, leftOk := left.(float64)
+		rightValue, rightOk := right.(float64)
+		if !leftOk || !rightOk {
+			return nil, &RuntimeError{t: operator, message: "Operands must be numbers."}
+		}
+
+		switch operator.tokenType {
+		case GREATER:
+			return leftValue > rightValue, nil
+		case GREATER_EQUAL:
+			return leftValue >= rightValue, nil
+		case LESS:
+			return leftValue < rightValue, nil
+		case LESS_EQUAL:
+			return leftValue <= rightValue, nil
+		case MINUS:
+			return leftValue - rightValue, nil
+		case SLASH:
+			return leftValue / rightValue, nil
+		case TILDE_SLASH:
+			return math.Floor(leftValue / rightValue), nil
+		case STAR:
+			return leftValue * rightValue, nil
+		case STAR_STAR:
+			return math.Pow(leftValue, rightValue), nil
+		case PERCENT:
+			return modulo(leftValue, rightValue), nil
+		}
+	case PLUS:
+		leftValue, leftOk := left.(float64)
+		rightValue, rightOk := right.(float64)
+		if leftOk && rightOk {
+			return leftValue + rightValue, nil
+		}
+
+		leftString, leftOk := left.(string)
+		rightString, rightOk := right.(string)
+		if leftOk && rightOk {
+			return leftString + rightString, nil
+		}
+
+		return nil, &RuntimeError{
+			t:       operator,
+			message: fmt.Sprintf("Operands must be two numbers or two strings (%v %v %v).", reflect.TypeOf(left), operator.lexeme, reflect.TypeOf(right)),
+		}
+	case BANG_EQUAL:
+		return !isEqual(left, right), nil
+	case EQUAL_EQUAL:
+		return isEqual(left, right), nil
+	}
+
+	// Unreachable
+	return nil, &RuntimeError{t: operator, message: "Unexpected binary expression"}
+}
+
+func (v *interpreter) visitCallExpr(e *Call[any]) (any, error) {
+	callee, err := v.evaluate(e.callee)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := []any{}
+	for _, argument := range e.arguments {
+		value, err := v.evaluate(argument)
+		if err != nil {
+			return nil, err
+		}
+
+		arguments = append(arguments, value)
+	}
+
+	return v.callValue(callee, arguments, e.paren)
+}
+
+// maxFrames is the deepest that Lox calls may nest. Like clox, a program
+// that recurses further is stopped with a runtime error rather than left to
+// exhaust the host's stack or memory.
+const maxFrames = 10000
+
+// pushFrame records a call to the named function made at paren.
+func (v *interpreter) pushFrame(name string, paren *token) error {
+	if len(v.frames) >= maxFrames {
+		return &RuntimeError{t: paren, message: "Stack overflow."}
+	}
+
+	v.frames = append(v.frames, StackFrame{Function: name, Line: paren.line, File: paren.file})
+	return nil
+}
+
+// callValue calls callee, reporting errors at paren, the token of the call.
+func (v *interpreter) callValue(callee any, arguments []any, paren *token) (any, error) {
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		host, ok := callee.(Callable)
+		if !ok {
+			return nil, &RuntimeError{t: paren, message: "Can only call functions and classes."}
+		}
+		function = newNativeFunction("host", host.Arity(), host.Call)
+	}
+
+	if function.arity() != Variadic && function.arity() != len(arguments) {
+		return nil, &RuntimeError{
+			t:       paren,
+			message: fmt.Sprintf("Expected %v arguments but got %v.", function.arity(), len(arguments)),
+		}
+	}
+
+	name, hasFrame := callName(function)
+	if hasFrame {
+		err := v.pushFrame(name, paren)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := function.call(v, arguments)
+	if err != nil {
+		var re *RuntimeError
+		var te *ThrowError
+		if !errors.As(err, &re) && !errors.As(err, &te) && !hasFrame {
+			// Errors from Go code have no position, so report them at the call.
+			re = &RuntimeError{t: paren, message: err.Error()}
+			err = re
+		}
+
+		// The innermost call records the stack as it was when the error
+		// occurred.
+		if re != nil && re.trace == nil {
+			re.trace = append([]StackFrame{}, v.frames...)
+		}
+	}
+
+	if hasFrame {
+		v.frames = v.frames[:len(v.frames)-1]
+	}
+
+	return result, err
+}
+
+func (v *interpreter) visitGroupingExpr(e *Grouping[any]) (any, error) {
+	return v.evaluate(e.expression)
+}
+
+func (v *interpreter) visitIndexExpr(e *Index[any]) (any, error) {
+	object, err := v.evaluate(e.object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := v.evaluate(e.index)
+	if err != nil {
+		return nil, err
+	}
+
+	return getIndex(object, e.bracket, index)
+}
+
+func getIndex(object any, bracket *token, index any) (any, error) {
+	collection, ok := object.(indexable)
+	if !ok {
+		return nil, &RuntimeError{t: bracket, message: "Only lists and maps can be indexed."}
+	}
+
+	return collection.getIndex(bracket, index)
+}
+
+func (v *interpreter) visitIndexSetExpr(e *IndexSet[any]) (any, error) {
+	object, err := v.evaluate(e.object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := v.evaluate(e.index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := v.evaluate(e.value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setIndex(object, e.bracket, index, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func setIndex(object any, bracket *token, index any, value any) error {
+	collection, ok := object.(indexable)
+	if !ok {
+		return &RuntimeError{t: bracket, message: "Only lists and maps can be indexed."}
+	}
+
+	return collection.setIndex(bracket, index, value)
+}
+
+func (v *interpreter) visitLambdaExpr(e *Lambda[any]) (any, error) {
+	return &LoxFunction{declaration: e.function, closure: v.env, isInitializer: false, globals: v.globals}, nil
+}
+
+func (v *interpreter) visitListExpr(e *List[any]) (any, error) {
+	elements := make([]any, len(e.elements))
+	for i, element := range e.elements {
+		value, err := v.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = value
+	}
+
+	return newLoxList(elements), nil
+}
+
+func (v *interpreter) visitMapExpr(e *Map[any]) (any, error) {
+	dict := newLoxMap()
+	for i, key := range e.keys {
+		k, err := v.evaluate(key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := v.evaluate(e.values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		err = dict.setIndex(e.brace, k, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dict, nil
+}
+
+func (v *interpreter) visitLiteralExpr(e *Literal[any]) (any, error) {
+	return e.value, nil
+}
+
+func (v *interpreter) visitLogicalExpr(e *Logical[any]) (any, error) {
+	left, err := v.evaluate(e.left)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.operator.tokenType == OR {
+		if isTruthy(left) {
+			return left, nil
+		}
+	} else {
+		if !isTruthy(left) {
+			return left, nil
+		}
+	}
+
+	return v.evaluate(e.right)
+}
+
+func (v *interpreter) visitUnaryExpr(e *Unary[any]) (any, error) {
+	right, err := v.evaluate(e.right)
+	if err != nil {
+		return nil, err
+	}
+
+	return unary(e.operator, right)
+}
+
+// unary applies a unary operator to its evaluated operand.
+func unary(operator *token, right any) (any, error) {
+	switch operator.tokenType {
+	case BANG:
+		return !isTruthy(right), nil
+	case MINUS:
+		value, ok := right.(float64)
+		if !ok {
+			return nil, &RuntimeError{t: operator, message: "Operand must be a number."}
+		}
+		return -value, nil
+	}
+
+	// Unreachable.
+	return nil, &RuntimeError{t: operator, message: "Unexpected unary expression"}
+}
+
+func (v *interpreter) visitVariableExpr(e *Variable[any]) (any, error) {
+	return v.lookUpVariable(e.name, e)
+}
+
+func (v *interpreter) visitBlockStmt(stmt *Block[any]) error {
+	return v.executeBlock(stmt.statements, newEnvironment(v.env))
+}
+
+func (v *interpreter) visitBreakStmt(stmt *Break[any]) error {
+	return &BreakError{}
+}
+
+func (v *interpreter) visitContinueStmt(stmt *Continue[any]) error {
+	return &ContinueError{}
+}
+
+func (v *interpreter) visitExpressionStmt(stmt *Expression[any]) error {
+	_, err := v.evaluate(stmt.expression)
+
+	return err
+}
+
+func (v *interpreter) visitIfStmt(stmt *If[any]) error {
+	value, err := v.evaluate(stmt.condition)
+	if err != nil {
+		return err
+	}
+
+	if isTruthy(value) {
+		err = v.execute(stmt.thenBranch)
+		if err != nil {
+			return err
+		}
+	} else if stmt.elseBranch != nil {
+		err = v.execute(stmt.elseBranch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *interpreter) visitPrintStmt(stmt *Print[any]) error {
+	value, err := v.evaluate(stmt.expression)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(v.stdout, stringify(value))
+	return nil
+}
+
+func (v *interpreter) visitReturnStmt(stmt *Return[any]) error {
+	var value any
+	var err error
+	if stmt.value != nil {
+		value, err = v.evaluate(stmt.value)
+	}
+
+	if err == nil {
+		err = &ReturnError{value}
+	}
+
+	return err
+}
+
+func (v *interpreter) visitThrowStmt(stmt *Throw[any]) error {
+	value, err := v.evaluate(stmt.value)
+	if err != nil {
+		return err
+	}
+
+	return &ThrowError{t: stmt.keyword, value: value, trace: append([]StackFrame{}, v.frames...)}
+}
+
+func (v *interpreter) visitTryStmt(stmt *Try[any]) error {
+	err := v.execute(stmt.tryBranch)
+	if err != nil && stmt.catchBranch != nil {
+		value, ok := caught(err)
+		if ok {
+			env := newEnvironment(v.env)
+			env.define(stmt.catchName, value)
+			err = v.executeBlock(stmt.catchBranch.statements, env)
+		}
+	}
+
+	if stmt.finallyBranch != nil {
+		// Leaving the finally block early replaces whatever was in flight.
+		finallyErr := v.execute(stmt.finallyBranch)
+		if finallyErr != nil {
+			return finallyErr
+		}
+	}
+
+	return err
+}
+
+func (v *interpreter) visitVarStmt(s *Var[any]) (err error) {
+	var value any
+	if s.initializer != nil {
+		value, err = v.evaluate(s.initializer)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = v.env.define(s.name, value)
+	return err
+}
+
+func (v *interpreter) visitWhileStmt(whileStmt *While[any]) error {
+	result, err := v.evaluate(whileStmt.condition)
+	if err != nil {
+		return err
+	}
+
+	for isTruthy(result) {
+		err = v.execute(whileStmt.body)
+		if err != nil {
+			if _, ok := err.(*BreakError); ok {
+				break
+			}
+			if _, ok := err.(*ContinueError); !ok {
+				return err
+			}
+		}
+
+		if whileStmt.increment != nil {
+			_, err = v.evaluate(whileStmt.increment)
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err = v.evaluate(whileStmt.condition)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *interpreter) visitForInStmt(stmt *ForIn[any]) error {
+	iterable, err := v.evaluate(stmt.iterable)
+	if err != nil {
+		return err
+	}
+
+	next, err := v.iterate(stmt.keyword, iterable)
+	if err != nil {
+		return err
+	}
+
+	for {
+		value, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		env := newEnvironment(v.env)
+		env.define(stmt.name, value)
+		err = v.executeBlock([]Stmt[any]{stmt.body}, env)
+		if err != nil {
+			if _, ok := err.(*BreakError); ok {
+				break
+			}
+			if _, ok := err.(*ContinueError); !ok {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *interpreter) visitFunctionStmt(funcStmt *Function[any]) error {
+	function := &LoxFunction{declaration: funcStmt, closure: v.env, isInitializer: false, globals: v.globals}
+	err := v.env.define(funcStmt.name, function)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (v *interpreter) visitClassStmt(stmt *Class[any]) error {
+	var superclass *LoxClass
+	if stmt.superclass != nil {
+		sc, err := v.evaluate(stmt.superclass)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		superclass, ok = sc.(*LoxClass)
+		if !ok {
+			return &RuntimeError{t: stmt.superclass.name, message: "Superclass must be a class."}
+		}
+	}
+
+	err := v.env.define(stmt.name, nil)
+	if err != nil {
+		return err
+	}
+
+	if stmt.superclass != nil {
+		v.env = newEnvironment(v.env)
+		v.env.define(&token{tokenType: SUPER, lexeme: "super"}, superclass)
+	}
+
+	methods := map[string]classMethod{}
+	for _, method := range stmt.methods {
+		isInitializer := method.name.lexeme == "init"
+		methods[method.name.lexeme] = &LoxFunction{
+			declaration:   method,
+			closure:       v.env,
+			isInitializer: isInitializer,
+			globals:       v.globals,
+		}
+	}
+
+	class := newLoxClass(stmt.name.lexeme, superclass, methods)
+
+	if stmt.superclass != nil {
+		v.env = v.env.enclosing
+	}
+
+	v.env.redefine(stmt.name, class)
+
+	return nil
+}
+
+func (v *interpreter) visitGetExpr(expr *Get[any]) (any, error) {
+	object, err := v.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+
+	return getProperty(object, expr.name)
+}
+
+// getProperty returns the named property of an instance, host object or
+// built-in value.
+func getProperty(object any, name *token) (any, error) {
+	instance, ok := object.(*LoxInstance)
+	if ok {
+		return instance.get(name)
+	}
+
+	list, ok := object.(*LoxList)
+	if ok {
+		return list.get(name)
+	}
+
+	dict, ok := object.(*LoxMap)
+	if ok {
+		return dict.get(name)
+	}
+
+	str, ok := object.(string)
+	if ok {
+		return stringMethod(str, name)
+	}
+
+	host, ok := object.(HostObject)
+	if ok {
+		value, ok := host.GetProperty(name.lexeme)
+		if ok {
+			return value, nil
+		}
+
+		method, ok := host.Method(name.lexeme)
+		if ok {
+			return method, nil
+		}
+
+		return nil, &RuntimeError{t: name, message: fmt.Sprintf("Undefined property '%v'.", name.lexeme)}
+	}
+
+	return nil, &RuntimeError{
+		t:       name,
+		message: "Only instances have properties.",
+	}
+}
+
+func (v *interpreter) visitSetExpr(expr *Set[any]) (any, error) {
+	object, err := v.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkFields(object, expr.name)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := v.evaluate(expr.value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setProperty(object, expr.name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// checkFields reports an error if object has no fields that could be set. It
+// is checked before the value being assigned is evaluated.
+func checkFields(object any, name *token) error {
+	switch object.(type) {
+	case *LoxInstance, HostObject:
+		return nil
+	}
+
+	return &RuntimeError{t: name, message: "Only instances have fields."}
+}
+
+func setProperty(object any, name *token, value any) error {
+	host, ok := object.(HostObject)
+	if ok {
+		err := host.SetProperty(name.lexeme, value)
+		if err != nil {
+			return &RuntimeError{t: name, message: err.Error()}
+		}
+		return nil
+	}
+
+	object.(*LoxInstance).set(name, value)
+
+	return nil
+}
+
+func (v *interpreter) visitThisExpr(expr *This[any]) (any, error) {
+	return v.lookUpVariable(expr.keyword, expr)
+}
+
+func (v *interpreter) visitSuperExpr(expr *Super[any]) (any, error) {
+	// The superclass and instance are each the only variable in their scope.
+	distance := v.locals[expr].depth
+	superclass, ok := v.env.getAt(distance, 0).(*LoxClass)
+	if !ok {
+		return nil, &RuntimeError{t: expr.keyword, message: "Unable to cast superclass."}
+	}
+
+	instance, ok := v.env.getAt(distance-1, 0).(*LoxInstance)
+	if !ok {
+		return nil, &RuntimeError{t: expr.keyword, message: "Unable to cast superclass instance."}
+	}
+
+	method := superclass.findMethod(expr.method.lexeme)
+
+	if method == nil {
+		return nil, &RuntimeError{
+			t:       expr.method,
+			message: fmt.Sprintf("Undefined property '%v'.", expr.method.lexeme),
+		}
+	}
+
+	return method.bind(instance)
+}
+
+func (v *interpreter) evaluate(e Expr[any]) (any, error) {
+	return e.accept(v)
+}
+
+func (v *interpreter) execute(stmt Stmt[any]) error {
+	return stmt.accept(v)
+}
+
+func (v *interpreter) executeBlock(statements []Stmt[any], env *Environment) (err error) {
+	prevEnv := v.env
+	defer func() { v.env = prevEnv }()
+
+	v.env = env
+	for _, s := range statements {
+		err = v.execute(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *interpreter) resolve(e Expr[any], depth int, slot int) {
+	v.locals[e] = binding{depth: depth, slot: slot}
+}
+
+func (v *interpreter) lookUpVariable(name *token, expr Expr[any]) (any, error) {
+	b, ok := v.locals[expr]
+	if ok {
+		return v.env.getAt(b.depth, b.slot), nil
+	} else {
+		return v.globals.get(name)
+	}
+}
+
+func stringify(object any) string {
+	if object == nil {
+		return "nil"
+	}
+
+	return fmt.Sprintf("%v", object)
+}
+
+func isEqual(a, b any) bool {
+	switch a.(type) {
+	case nil:
+		return b == nil
+	case bool, float64, string:
+		return a == b
+	}
+
+	// A host value may be of a type that Go can't compare, which is only
+	// equal to itself if it is the same reference.
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.ValueOf(a).Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+func isTruthy(object any) bool {
+	if object == nil {
+		return false
+	}
+
+	boolValue, ok := object.(bool)
+	if ok {
+		return boolValue
+	}
+
+	return true
+}
@@ -0,0 +1,107 @@
+package lox
+
+import "fmt"
+
+// iterator returns the next value of a sequence, or false once it is done.
+type iterator func() (any, bool, error)
+
+// iterate returns an iterator over the elements of a list, the keys of a map,
+// the characters of a string, or the values of an instance that follows the
+// iterator protocol. An instance is iterated by calling its iter() method, if
+// it has one, to get an iterator object. The iterator, which may be the
+// instance itself, is then asked hasNext() before each call to next().
+func (v *interpreter) iterate(keyword *token, iterable any) (iterator, error) {
+	switch iterable := iterable.(type) {
+	case *LoxList:
+		// Lists are read as they are iterated, so elements pushed by the loop
+		// body are visited too.
+		i := 0
+		return func() (any, bool, error) {
+			if i >= len(iterable.elements) {
+				return nil, false, nil
+			}
+			i += 1
+			return iterable.elements[i-1], true, nil
+		}, nil
+	case *LoxMap:
+		keys := append([]any{}, iterable.keys...)
+		return sliceIterator(keys), nil
+	case string:
+		characters := []any{}
+		for _, r := range iterable {
+			characters = append(characters, string(r))
+		}
+		return sliceIterator(characters), nil
+	case *LoxInstance:
+		return v.instanceIterator(keyword, iterable)
+	}
+
+	return nil, &RuntimeError{t: keyword, message: fmt.Sprintf("Can't iterate over %v.", typeName(iterable))}
+}
+
+func sliceIterator(values []any) iterator {
+	i := 0
+	return func() (any, bool, error) {
+		if i >= len(values) {
+			return nil, false, nil
+		}
+		i += 1
+		return values[i-1], true, nil
+	}
+}
+
+func (v *interpreter) instanceIterator(keyword *token, instance *LoxInstance) (iterator, error) {
+	it := instance
+	iter, err := v.method(keyword, instance, "iter")
+	if err != nil {
+		return nil, err
+	}
+	if iter != nil {
+		value, err := v.callValue(iter, []any{}, keyword)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		it, ok = value.(*LoxInstance)
+		if !ok {
+			return nil, &RuntimeError{t: keyword, message: "iter() must return an instance."}
+		}
+	}
+
+	hasNext, err := v.method(keyword, it, "hasNext")
+	if err != nil {
+		return nil, err
+	}
+	next, err := v.method(keyword, it, "next")
+	if err != nil {
+		return nil, err
+	}
+	if hasNext == nil || next == nil {
+		return nil, &RuntimeError{
+			t:       keyword,
+			message: fmt.Sprintf("Can't iterate over %v without hasNext() and next() methods.", typeName(it)),
+		}
+	}
+
+	return func() (any, bool, error) {
+		more, err := v.callValue(hasNext, []any{}, keyword)
+		if err != nil || !isTruthy(more) {
+			return nil, false, err
+		}
+
+		value, err := v.callValue(next, []any{}, keyword)
+		return value, err == nil, err
+	}, nil
+}
+
+// method returns the named method bound to instance, or nil if its class
+// has no such method.
+func (v *interpreter) method(keyword *token, instance *LoxInstance, name string) (LoxCallable, error) {
+	method := instance.class.findMethod(name)
+	if method == nil {
+		return nil, nil
+	}
+
+	return method.bind(instance)
+}
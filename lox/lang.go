@@ -0,0 +1,186 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Single-character tokens.
+	LEFT_PAREN    = iota
+	RIGHT_PAREN   = iota
+	LEFT_BRACE    = iota
+	RIGHT_BRACE   = iota
+	LEFT_BRACKET  = iota
+	RIGHT_BRACKET = iota
+	COLON         = iota
+	COMMA         = iota
+	DOT           = iota
+	MINUS         = iota
+	PERCENT       = iota
+	PLUS          = iota
+	SEMICOLON     = iota
+	SLASH         = iota
+	STAR          = iota
+
+	// One or two character tokens.
+	BANG          = iota
+	ARROW         = iota
+	BANG_EQUAL    = iota
+	EQUAL         = iota
+	EQUAL_EQUAL   = iota
+	GREATER       = iota
+	GREATER_EQUAL = iota
+	LESS          = iota
+	LESS_EQUAL    = iota
+	TILDE_SLASH   = iota
+	STAR_STAR     = iota
+
+	// Literals.
+	IDENTIFIER = iota
+	STRING     = iota
+	NUMBER     = iota
+
+	// Keywords.
+	AND      = iota
+	BREAK    = iota
+	CATCH    = iota
+	CLASS    = iota
+	CONTINUE = iota
+	ELSE     = iota
+	FALSE    = iota
+	FINALLY  = iota
+	FUN      = iota
+	FOR      = iota
+	IF       = iota
+	IMPORT   = iota
+	IN       = iota
+	NIL      = iota
+	OR       = iota
+	PRINT    = iota
+	RETURN   = iota
+	SUPER    = iota
+	THIS     = iota
+	THROW    = iota
+	TRUE     = iota
+	TRY      = iota
+	VAR      = iota
+	WHILE    = iota
+
+	ERROR = iota
+	EOF   = iota
+)
+
+var keywords = map[string]int{
+	"and":      AND,
+	"break":    BREAK,
+	"catch":    CATCH,
+	"class":    CLASS,
+	"continue": CONTINUE,
+	"else":     ELSE,
+	"false":    FALSE,
+	"finally":  FINALLY,
+	"for":      FOR,
+	"fun":      FUN,
+	"if":       IF,
+	"import":   IMPORT,
+	"in":       IN,
+	"nil":      NIL,
+	"or":       OR,
+	"print":    PRINT,
+	"return":   RETURN,
+	"super":    SUPER,
+	"this":     THIS,
+	"throw":    THROW,
+	"true":     TRUE,
+	"try":      TRY,
+	"var":      VAR,
+	"while":    WHILE,
+}
+
+type token struct {
+	tokenType int
+	lexeme    string
+	literal   any
+	line      int
+
+	// The span of the lexeme within source, used to point at it in errors.
+	// Tokens made by the interpreter rather than the scanner have no source.
+	source string
+	offset int
+	column int
+	length int
+	// The module the token was scanned from, or empty for the main script.
+	file string
+}
+
+func newToken(tokenType int, lexeme string, literal any, line int) *token {
+	t := &token{
+		tokenType: tokenType,
+		lexeme:    lexeme,
+		literal:   literal,
+		line:      line,
+	}
+	return t
+}
+
+func (t *token) String() string {
+	return fmt.Sprintf("%v %v %v", t.tokenType, t.lexeme, t.literal)
+}
+
+type LoxCallable interface {
+	arity() int
+	call(v *interpreter, arguments []any) (any, error)
+}
+
+// snippet renders the source line containing the token with a caret under
+// its lexeme, or returns an empty string if the token has no source.
+// position describes where t is for the header of an error, such as
+// "line 3:7", which gives the column of the token's first character, or
+// "line 3:7 of lib/util.lox" for a token in an imported module.
+func (t *token) position() string {
+	position := fmt.Sprintf("line %v", t.line)
+	if t.column > 0 {
+		position += fmt.Sprintf(":%v", t.column)
+	}
+	if t.file != "" {
+		position += " of " + t.file
+	}
+
+	return position
+}
+
+func (t *token) snippet() string {
+	if t.source == "" {
+		return ""
+	}
+
+	lineStart := strings.LastIndexByte(t.source[:t.offset], '\n') + 1
+	lineEnd := strings.IndexByte(t.source[t.offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(t.source)
+	} else {
+		lineEnd += t.offset
+	}
+
+	// Tabs are copied so that the caret lines up beneath a tabbed line.
+	var padding strings.Builder
+	for _, r := range t.source[lineStart:t.offset] {
+		if r == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+
+	end := min(t.offset+t.length, lineEnd)
+	width := max(utf8.RuneCountInString(t.source[t.offset:end]), 1)
+
+	line := strconv.Itoa(strings.Count(t.source[:t.offset], "\n") + 1)
+	gutter := strings.Repeat(" ", len(line))
+	text := strings.TrimRight(t.source[lineStart:lineEnd], "\r")
+
+	return fmt.Sprintf("\n %v | %v\n %v | %v%v", line, text, gutter, padding.String(), strings.Repeat("^", width))
+}
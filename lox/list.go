@@ -0,0 +1,193 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// indexable is implemented by the collections that support subscripts.
+type indexable interface {
+	getIndex(bracket *token, index any) (any, error)
+	setIndex(bracket *token, index any, value any) error
+}
+
+type LoxList struct {
+	elements []any
+}
+
+func newLoxList(elements []any) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+func (l *LoxList) String() string {
+	return formatValue(l, map[any]bool{})
+}
+
+// index converts a Lox number to an index into the list, counting back from
+// the end when it is negative.
+func (l *LoxList) index(bracket *token, value any) (int, error) {
+	number, ok := value.(float64)
+	if !ok || number != float64(int(number)) {
+		return 0, &RuntimeError{t: bracket, message: "List index must be an integer."}
+	}
+
+	i := int(number)
+	if i < 0 {
+		i += len(l.elements)
+	}
+	if i < 0 || i >= len(l.elements) {
+		return 0, &RuntimeError{t: bracket, message: fmt.Sprintf("List index %v out of range.", number)}
+	}
+
+	return i, nil
+}
+
+func (l *LoxList) getIndex(bracket *token, index any) (any, error) {
+	i, err := l.index(bracket, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.elements[i], nil
+}
+
+func (l *LoxList) setIndex(bracket *token, index any, value any) error {
+	i, err := l.index(bracket, index)
+	if err != nil {
+		return err
+	}
+
+	l.elements[i] = value
+	return nil
+}
+
+func (l *LoxList) get(name *token) (any, error) {
+	switch name.lexeme {
+	case "len":
+		return newBuiltinMethod("len", 0, func(v *interpreter, arguments []any) (any, error) {
+			return float64(len(l.elements)), nil
+		}), nil
+	case "push":
+		return newBuiltinMethod("push", 1, func(v *interpreter, arguments []any) (any, error) {
+			l.elements = append(l.elements, arguments[0])
+			return nil, nil
+		}), nil
+	case "pop":
+		return newBuiltinMethod("pop", 0, func(v *interpreter, arguments []any) (any, error) {
+			if len(l.elements) == 0 {
+				return nil, fmt.Errorf("Can't pop from an empty list.")
+			}
+			last := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return last, nil
+		}), nil
+	case "slice":
+		return newBuiltinMethod("slice", Variadic, func(v *interpreter, arguments []any) (any, error) {
+			start, end, err := sliceBounds(arguments, len(l.elements))
+			if err != nil {
+				return nil, err
+			}
+			return newLoxList(append([]any{}, l.elements[start:end]...)), nil
+		}), nil
+	case "map":
+		return newBuiltinMethod("map", 1, func(v *interpreter, arguments []any) (any, error) {
+			result := make([]any, len(l.elements))
+			for i, element := range l.elements {
+				value, err := v.callValue(arguments[0], []any{element}, name)
+				if err != nil {
+					return nil, err
+				}
+				result[i] = value
+			}
+			return newLoxList(result), nil
+		}), nil
+	case "filter":
+		return newBuiltinMethod("filter", 1, func(v *interpreter, arguments []any) (any, error) {
+			result := []any{}
+			for _, element := range l.elements {
+				keep, err := v.callValue(arguments[0], []any{element}, name)
+				if err != nil {
+					return nil, err
+				}
+				if isTruthy(keep) {
+					result = append(result, element)
+				}
+			}
+			return newLoxList(result), nil
+		}), nil
+	}
+
+	return nil, &RuntimeError{t: name, message: fmt.Sprintf("Undefined property '%v'.", name.lexeme)}
+}
+
+// sliceBounds returns the range selected by the arguments (start, end) of a
+// slice method, where end is optional and either may count back from the end
+// when negative. Bounds beyond the sequence are clamped to it.
+func sliceBounds(arguments []any, length int) (int, int, error) {
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return 0, 0, fmt.Errorf("Expected 1 or 2 arguments but got %v.", len(arguments))
+	}
+
+	bounds := []int{0, length}
+	for i, argument := range arguments {
+		if i == 1 && argument == nil {
+			break
+		}
+
+		number, ok := argument.(float64)
+		if !ok || number != float64(int(number)) {
+			return 0, 0, fmt.Errorf("Slice bounds must be integers.")
+		}
+
+		bound := int(number)
+		if bound < 0 {
+			bound += length
+		}
+		bounds[i] = min(max(bound, 0), length)
+	}
+
+	if bounds[1] < bounds[0] {
+		bounds[1] = bounds[0]
+	}
+
+	return bounds[0], bounds[1], nil
+}
+
+// formatValue renders a value as print would, except that strings nested in
+// a collection are quoted. A collection that contains itself is shown as
+// [...] or {...}.
+func formatValue(value any, seen map[any]bool) string {
+	switch value := value.(type) {
+	case string:
+		if len(seen) > 0 {
+			return fmt.Sprintf("%q", value)
+		}
+		return value
+	case *LoxList:
+		if seen[value] {
+			return "[...]"
+		}
+		seen[value] = true
+		defer delete(seen, value)
+
+		parts := make([]string, len(value.elements))
+		for i, element := range value.elements {
+			parts[i] = formatValue(element, seen)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *LoxMap:
+		if seen[value] {
+			return "{...}"
+		}
+		seen[value] = true
+		defer delete(seen, value)
+
+		parts := make([]string, len(value.keys))
+		for i, key := range value.keys {
+			parts[i] = formatValue(key, seen) + ": " + formatValue(value.entries[key], seen)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+
+	return stringify(value)
+}
@@ -0,0 +1,252 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Value is anything a Lox program can produce: nil, bool, float64, string or
+// one of the runtime types such as *LoxFunction, *LoxClass and *LoxInstance.
+type Value = any
+
+// Backend selects how a VM executes programs.
+type Backend int
+
+const (
+	// TreeWalker evaluates the syntax tree directly.
+	TreeWalker Backend = iota
+	// Bytecode compiles programs to bytecode for a stack-based virtual
+	// machine.
+	Bytecode
+)
+
+// VM is an embeddable Lox interpreter. Globals defined by one call to Eval
+// remain visible to the next until Reset is called.
+type VM struct {
+	inter    *interpreter
+	machine  *machine
+	resolver *resolver
+	backend  Backend
+	natives  map[string]*nativeFunction
+	stdout   io.Writer
+	path     []string
+}
+
+func New() *VM {
+	vm := &VM{natives: map[string]*nativeFunction{}, stdout: os.Stdout}
+	vm.Reset()
+	return vm
+}
+
+// SetOutput sets the writer that print statements write to, which is
+// os.Stdout by default.
+func (vm *VM) SetOutput(w io.Writer) {
+	vm.stdout = w
+	vm.inter.stdout = w
+}
+
+// Reset discards the interpreter state, leaving only the native globals
+// defined.
+func (vm *VM) Reset() {
+	vm.inter = newInterpreter(vm.stdout)
+	vm.machine = newMachine(vm.inter)
+	vm.resolver = newResolver(vm.inter)
+	vm.inter.searchPath = vm.path
+	for name, native := range vm.natives {
+		vm.inter.builtins[name] = native
+		vm.inter.globals.values[name] = native
+	}
+}
+
+// SetBackend selects how programs are executed, which is TreeWalker by
+// default. Both backends share the globals and natives of the VM.
+func (vm *VM) SetBackend(backend Backend) {
+	vm.backend = backend
+}
+
+// SetSearchPath sets the directories searched, in order, for an imported
+// module that is not found relative to the importing file.
+func (vm *VM) SetSearchPath(dirs ...string) {
+	vm.path = dirs
+	vm.inter.searchPath = dirs
+}
+
+// Define registers fn as a global native function taking arity arguments, or
+// any number of arguments if arity is Variadic.
+func (vm *VM) Define(name string, arity int, fn NativeFunc) {
+	vm.defineNative(newNativeFunction(name, arity, fn))
+}
+
+// Register binds an ordinary Go function, such as func(string, float64)
+// string, as a global native function. Arguments are converted from Lox values
+// to the parameter types, and a mismatch is reported as a runtime error.
+func (vm *VM) Register(name string, fn any) error {
+	native, err := bindNative(name, fn)
+	if err != nil {
+		return err
+	}
+
+	vm.defineNative(native)
+	return nil
+}
+
+func (vm *VM) defineNative(native *nativeFunction) {
+	vm.natives[native.name] = native
+	vm.inter.builtins[native.name] = native
+	vm.inter.globals.values[native.name] = native
+}
+
+// Eval scans, parses, resolves and executes source.
+func (vm *VM) Eval(source string) error {
+	return vm.eval(source, false)
+}
+
+// EvalInteractive is like Eval, except that the source may end with a bare
+// expression without a semicolon, whose value is printed.
+func (vm *VM) EvalInteractive(source string) error {
+	return vm.eval(source, true)
+}
+
+// RunFile executes the Lox script at path. Modules it imports are found
+// relative to the script.
+func (vm *VM) RunFile(path string) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return vm.EvalFile(path, string(bytes))
+}
+
+// EvalFile is like Eval for source that was read from the script at path, so
+// that modules it imports are found relative to the script.
+func (vm *VM) EvalFile(path string, source string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	vm.inter.files = append(vm.inter.files, abs)
+	defer func() {
+		vm.inter.files = vm.inter.files[:len(vm.inter.files)-1]
+	}()
+
+	return vm.Eval(source)
+}
+
+func (vm *VM) eval(source string, repl bool) error {
+	scanner := newScanner(source)
+	tokens, scanErr := scanner.scanTokens()
+
+	parser := newParser[any](tokens)
+	parser.repl = repl
+	statements, err := parser.parse()
+	if err != nil {
+		return errors.Join(scanErr, err)
+	}
+
+	err = vm.resolver.resolve(statements)
+	if err != nil {
+		// A failed resolve can leave scopes open, so start the next one clean.
+		vm.resolver = newResolver(vm.inter)
+		return errors.Join(scanErr, err)
+	}
+
+	if scanErr != nil {
+		return scanErr
+	}
+
+	if vm.backend == Bytecode {
+		return vm.machine.interpret(statements)
+	}
+
+	return vm.inter.interpret(statements)
+}
+
+// SetGlobal defines name in the global scope, replacing any existing value.
+func (vm *VM) SetGlobal(name string, value Value) {
+	vm.inter.globals.values[name] = toLox(value)
+}
+
+// GetGlobal returns the value of the global variable name, if it is defined.
+func (vm *VM) GetGlobal(name string) (Value, bool) {
+	value, ok := vm.inter.globals.values[name]
+	return value, ok
+}
+
+// Call invokes a Lox function or class with the given arguments.
+func (vm *VM) Call(fn Value, args ...Value) (Value, error) {
+	callable, ok := fn.(LoxCallable)
+	if !ok {
+		return nil, fmt.Errorf("can only call functions and classes, got %v", stringify(fn))
+	}
+
+	if callable.arity() != Variadic && callable.arity() != len(args) {
+		return nil, fmt.Errorf("expected %v arguments but got %v", callable.arity(), len(args))
+	}
+
+	arguments := make([]Value, len(args))
+	for i, arg := range args {
+		arguments[i] = toLox(arg)
+	}
+
+	result, err := callable.call(vm.inter, arguments)
+	if err != nil {
+		return nil, uncaught(err)
+	}
+
+	return result, nil
+}
+
+// IsComplete reports whether source can be run as it stands, or whether it
+// stops part way through a declaration and more input is expected.
+func IsComplete(source string) bool {
+	scanner := newScanner(source)
+	tokens, _ := scanner.scanTokens()
+	if len(scanner.errors) > 0 {
+		return scanner.errors[len(scanner.errors)-1].message != unterminatedString
+	}
+
+	depth := 0
+	for _, t := range tokens {
+		switch t.tokenType {
+		case LEFT_PAREN, LEFT_BRACE, LEFT_BRACKET:
+			depth += 1
+		case RIGHT_PAREN, RIGHT_BRACE, RIGHT_BRACKET:
+			depth -= 1
+		}
+	}
+	if depth > 0 {
+		return false
+	}
+
+	parser := newParser[any](tokens)
+	parser.repl = true
+	parser.parse()
+	return len(parser.errors) == 0 || parser.errors[0].t.tokenType != EOF
+}
+
+// PrintAST writes the syntax tree of source to w.
+func PrintAST(source string, w io.Writer) error {
+	scanner := newScanner(source)
+	tokens, scanErr := scanner.scanTokens()
+
+	parser := newParser[string](tokens)
+	statements, err := parser.parse()
+	if err != nil || scanErr != nil {
+		return errors.Join(scanErr, err)
+	}
+
+	printer := &astPrinter{out: w}
+	for _, s := range statements {
+		err = s.accept(printer)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,658 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// closure is a compiled function together with the variables it captured and
+// the global scope of the module that declared it.
+type closure struct {
+	fn       *function
+	upvalues []*upvalue
+	globals  *Environment
+	m        *machine
+}
+
+func (c *closure) arity() int {
+	return c.fn.arity
+}
+
+func (c *closure) call(v *interpreter, arguments []any) (any, error) {
+	return c.m.callClosure(c, nil, arguments)
+}
+
+func (c *closure) bind(instance *LoxInstance) (LoxCallable, error) {
+	return &boundMethod{receiver: instance, method: c}, nil
+}
+
+func (c *closure) String() string {
+	return c.fn.String()
+}
+
+// boundMethod is a method of a class compiled to bytecode that has been read
+// from an instance.
+type boundMethod struct {
+	receiver *LoxInstance
+	method   *closure
+}
+
+func (b *boundMethod) arity() int {
+	return b.method.fn.arity
+}
+
+func (b *boundMethod) call(v *interpreter, arguments []any) (any, error) {
+	return b.method.m.callClosure(b.method, b.receiver, arguments)
+}
+
+func (b *boundMethod) String() string {
+	return b.method.String()
+}
+
+// upvalue is a variable captured by a closure. It refers to a stack slot
+// until the variable goes out of scope, when the value moves into the
+// upvalue itself.
+type upvalue struct {
+	slot   int
+	open   bool
+	closed any
+	// The next open upvalue, which refers to a lower slot.
+	next *upvalue
+}
+
+type callFrame struct {
+	closure *closure
+	ip      int
+	// The stack index of slot zero.
+	base int
+}
+
+// handler is an exception handler installed by a try statement.
+type handler struct {
+	// Whether the handler is for a catch block, which only handles errors
+	// that can be caught, rather than a finally block.
+	catch bool
+	// The frame and stack height to restore, and the code to continue at.
+	frame  int
+	sp     int
+	target int
+	// The number of calls on the stack trace.
+	calls int
+}
+
+// pendingError holds an error while a finally block runs, after which it is
+// raised again.
+type pendingError struct {
+	err error
+}
+
+// machine executes bytecode on a stack of values. It shares the runtime
+// values, natives and stack trace of the interpreter, which it calls on to
+// run built-in methods that call back into Lox code.
+type machine struct {
+	inter        *interpreter
+	stack        []any
+	frames       []callFrame
+	handlers     []handler
+	openUpvalues *upvalue
+}
+
+func newMachine(inter *interpreter) *machine {
+	return &machine{inter: inter}
+}
+
+// interpret compiles and runs a program in the interpreter's global scope.
+func (m *machine) interpret(statements []Stmt[any]) error {
+	fn, err := compile(statements)
+	if err != nil {
+		return err
+	}
+
+	m.stack = m.stack[:0]
+	m.frames = m.frames[:0]
+	m.handlers = m.handlers[:0]
+	m.openUpvalues = nil
+
+	script := &closure{fn: fn, globals: m.inter.globals, m: m}
+	_, err = m.callClosure(script, nil, nil)
+	return uncaught(err)
+}
+
+// runModule compiles and runs a module with env as its global scope.
+func (m *machine) runModule(statements []Stmt[any], env *Environment) error {
+	fn, err := compile(statements)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.callClosure(&closure{fn: fn, globals: env, m: m}, nil, nil)
+	return err
+}
+
+// callClosure runs c to completion with receiver in slot zero, which lets Go
+// code, such as a built-in method, call back into compiled code.
+func (m *machine) callClosure(c *closure, receiver any, arguments []any) (any, error) {
+	base := len(m.stack)
+	m.stack = append(m.stack, receiver)
+	m.stack = append(m.stack, arguments...)
+	m.frames = append(m.frames, callFrame{closure: c, base: base})
+
+	return m.run(len(m.frames) - 1)
+}
+
+// run executes frames from the frame numbered base until it returns. An error
+// that is not handled within those frames unwinds them and is returned.
+func (m *machine) run(base int) (any, error) {
+	calls := len(m.inter.frames)
+	for {
+		result, err := m.execute(base)
+		if err == nil {
+			return result, nil
+		}
+
+		var re *RuntimeError
+		if errors.As(err, &re) && re.trace == nil {
+			re.trace = append([]StackFrame{}, m.inter.frames...)
+		}
+
+		if !m.unwind(base, err) {
+			sp := m.frames[base].base
+			m.closeUpvalues(sp)
+			m.stack = m.stack[:sp]
+			m.frames = m.frames[:base]
+			m.inter.frames = m.inter.frames[:calls]
+			return nil, err
+		}
+	}
+}
+
+// unwind transfers control to the innermost handler for err installed by
+// the frames from base, reporting whether there is one.
+func (m *machine) unwind(base int, err error) bool {
+	value, catchable := caught(err)
+	for len(m.handlers) > 0 {
+		h := m.handlers[len(m.handlers)-1]
+		if h.frame < base {
+			return false
+		}
+
+		m.handlers = m.handlers[:len(m.handlers)-1]
+		if h.catch && !catchable {
+			continue
+		}
+
+		m.closeUpvalues(h.sp)
+		m.stack = m.stack[:h.sp]
+		m.frames = m.frames[:h.frame+1]
+		m.frames[h.frame].ip = h.target
+		m.inter.frames = m.inter.frames[:h.calls]
+		if h.catch {
+			m.stack = append(m.stack, value)
+		} else {
+			m.stack = append(m.stack, &pendingError{err: err})
+		}
+		return true
+	}
+
+	return false
+}
+
+func (m *machine) push(value any) {
+	m.stack = append(m.stack, value)
+}
+
+func (m *machine) pop() any {
+	value := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return value
+}
+
+func (m *machine) peek(distance int) any {
+	return m.stack[len(m.stack)-1-distance]
+}
+
+// call calls the value below the argCount arguments on top of the stack.
+// Compiled code gets a new frame, which the caller continues in, while other
+// callables are called through the interpreter and their result replaces the
+// callee and arguments.
+func (m *machine) call(argCount int, paren *token) error {
+	base := len(m.stack) - argCount - 1
+	callee := m.stack[base]
+
+	var c *closure
+	var name string
+	switch callee := callee.(type) {
+	case *closure:
+		c, name = callee, callee.fn.name
+	case *boundMethod:
+		c, name = callee.method, callee.method.fn.name
+		m.stack[base] = callee.receiver
+	case *LoxClass:
+		initializer, ok := callee.findMethod("init").(*closure)
+		if ok {
+			c, name = initializer, callee.name
+			m.stack[base] = newLoxInstance(callee)
+		}
+	}
+
+	if c == nil {
+		arguments := append([]any{}, m.stack[base+1:]...)
+		result, err := m.inter.callValue(callee, arguments, paren)
+		if err != nil {
+			return err
+		}
+
+		m.stack = m.stack[:base]
+		m.push(result)
+		return nil
+	}
+
+	if c.fn.arity != argCount {
+		return &RuntimeError{
+			t:       paren,
+			message: fmt.Sprintf("Expected %v arguments but got %v.", c.fn.arity, argCount),
+		}
+	}
+
+	err := m.inter.pushFrame(name, paren)
+	if err != nil {
+		return err
+	}
+	m.frames = append(m.frames, callFrame{closure: c, base: base})
+	return nil
+}
+
+// invoke calls the named method of the receiver below the arguments on top
+// of the stack. A method of a compiled class is called without binding it.
+func (m *machine) invoke(name *token, argCount int, paren *token) error {
+	receiver := m.peek(argCount)
+	instance, ok := receiver.(*LoxInstance)
+	if ok {
+		_, isField := instance.fields[name.lexeme]
+		method, isClosure := instance.class.findMethod(name.lexeme).(*closure)
+		if !isField && isClosure {
+			if method.fn.arity != argCount {
+				return &RuntimeError{
+					t:       paren,
+					message: fmt.Sprintf("Expected %v arguments but got %v.", method.fn.arity, argCount),
+				}
+			}
+
+			err := m.inter.pushFrame(method.fn.name, paren)
+			if err != nil {
+				return err
+			}
+			m.frames = append(m.frames, callFrame{closure: method, base: len(m.stack) - argCount - 1})
+			return nil
+		}
+	}
+
+	callee, err := getProperty(receiver, name)
+	if err != nil {
+		return err
+	}
+
+	m.stack[len(m.stack)-argCount-1] = callee
+	return m.call(argCount, paren)
+}
+
+func (m *machine) captureUpvalue(slot int) *upvalue {
+	var previous *upvalue
+	u := m.openUpvalues
+	for u != nil && u.slot > slot {
+		previous = u
+		u = u.next
+	}
+
+	if u != nil && u.slot == slot {
+		return u
+	}
+
+	created := &upvalue{slot: slot, open: true, next: u}
+	if previous == nil {
+		m.openUpvalues = created
+	} else {
+		previous.next = created
+	}
+
+	return created
+}
+
+// closeUpvalues closes the upvalues that refer to slot last or above.
+func (m *machine) closeUpvalues(last int) {
+	for m.openUpvalues != nil && m.openUpvalues.slot >= last {
+		u := m.openUpvalues
+		u.closed = m.stack[u.slot]
+		u.open = false
+		m.openUpvalues = u.next
+	}
+}
+
+// execute runs the dispatch loop until the frame numbered base returns or an
+// instruction fails.
+func (m *machine) execute(base int) (any, error) {
+	frame := &m.frames[len(m.frames)-1]
+	code := frame.closure.fn.chunk.code
+
+	readShort := func() int {
+		frame.ip += 2
+		return int(code[frame.ip-2])<<8 | int(code[frame.ip-1])
+	}
+	readConstant := func() any {
+		return frame.closure.fn.chunk.constants[readShort()]
+	}
+	// After a call or return, continue in the frame on top. Instructions
+	// that may call back into Lox code, such as an instance's iterator
+	// methods, must also reload because the calls can grow m.frames.
+	reload := func() {
+		frame = &m.frames[len(m.frames)-1]
+		code = frame.closure.fn.chunk.code
+	}
+
+	for {
+		start := frame.ip
+		t := frame.closure.fn.chunk.tokens[start]
+		op := code[frame.ip]
+		frame.ip += 1
+
+		switch op {
+		case OP_CONSTANT:
+			m.push(readConstant())
+		case OP_NIL:
+			m.push(nil)
+		case OP_TRUE:
+			m.push(true)
+		case OP_FALSE:
+			m.push(false)
+		case OP_POP:
+			m.stack = m.stack[:len(m.stack)-1]
+		case OP_GET_LOCAL:
+			slot := int(code[frame.ip])
+			frame.ip += 1
+			m.push(m.stack[frame.base+slot])
+		case OP_SET_LOCAL:
+			slot := int(code[frame.ip])
+			frame.ip += 1
+			m.stack[frame.base+slot] = m.peek(0)
+		case OP_GET_GLOBAL:
+			name := readConstant().(string)
+			value, ok := frame.closure.globals.values[name]
+			if !ok {
+				return nil, &RuntimeError{t: t, message: "Undefined variable '" + name + "'."}
+			}
+			m.push(value)
+		case OP_DEFINE_GLOBAL:
+			name := readConstant().(string)
+			frame.closure.globals.values[name] = m.pop()
+		case OP_SET_GLOBAL:
+			name := readConstant().(string)
+			_, ok := frame.closure.globals.values[name]
+			if !ok {
+				return nil, &RuntimeError{t: t, message: "Undefined variable '" + name + "'."}
+			}
+			frame.closure.globals.values[name] = m.peek(0)
+		case OP_GET_UPVALUE:
+			u := frame.closure.upvalues[code[frame.ip]]
+			frame.ip += 1
+			if u.open {
+				m.push(m.stack[u.slot])
+			} else {
+				m.push(u.closed)
+			}
+		case OP_SET_UPVALUE:
+			u := frame.closure.upvalues[code[frame.ip]]
+			frame.ip += 1
+			if u.open {
+				m.stack[u.slot] = m.peek(0)
+			} else {
+				u.closed = m.peek(0)
+			}
+		case OP_GET_PROPERTY:
+			readShort()
+			value, err := getProperty(m.pop(), t)
+			if err != nil {
+				return nil, err
+			}
+			m.push(value)
+		case OP_CHECK_FIELDS:
+			err := checkFields(m.peek(0), t)
+			if err != nil {
+				return nil, err
+			}
+		case OP_SET_PROPERTY:
+			readShort()
+			value := m.pop()
+			err := setProperty(m.pop(), t, value)
+			if err != nil {
+				return nil, err
+			}
+			m.push(value)
+		case OP_GET_SUPER:
+			readShort()
+			superclass := m.pop().(*LoxClass)
+			instance := m.pop().(*LoxInstance)
+			method := superclass.findMethod(t.lexeme)
+			if method == nil {
+				return nil, &RuntimeError{t: t, message: fmt.Sprintf("Undefined property '%v'.", t.lexeme)}
+			}
+			bound, err := method.bind(instance)
+			if err != nil {
+				return nil, err
+			}
+			m.push(bound)
+		case OP_GET_INDEX:
+			index := m.pop()
+			value, err := getIndex(m.pop(), t, index)
+			if err != nil {
+				return nil, err
+			}
+			m.push(value)
+		case OP_SET_INDEX:
+			value := m.pop()
+			index := m.pop()
+			err := setIndex(m.pop(), t, index, value)
+			if err != nil {
+				return nil, err
+			}
+			m.push(value)
+		case OP_EQUAL:
+			b := m.pop()
+			m.stack[len(m.stack)-1] = isEqual(m.peek(0), b)
+		case OP_NOT_EQUAL:
+			b := m.pop()
+			m.stack[len(m.stack)-1] = !isEqual(m.peek(0), b)
+		case OP_GREATER, OP_GREATER_EQUAL, OP_LESS, OP_LESS_EQUAL,
+			OP_ADD, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE, OP_INT_DIVIDE, OP_MODULO, OP_POWER:
+			b := m.pop()
+			a := m.peek(0)
+			x, ok := a.(float64)
+			y, ok2 := b.(float64)
+			if !ok || !ok2 {
+				value, err := binary(t, a, b)
+				if err != nil {
+					return nil, err
+				}
+				m.stack[len(m.stack)-1] = value
+				continue
+			}
+
+			var value any
+			switch op {
+			case OP_GREATER:
+				value = x > y
+			case OP_GREATER_EQUAL:
+				value = x >= y
+			case OP_LESS:
+				value = x < y
+			case OP_LESS_EQUAL:
+				value = x <= y
+			case OP_ADD:
+				value = x + y
+			case OP_SUBTRACT:
+				value = x - y
+			case OP_MULTIPLY:
+				value = x * y
+			case OP_DIVIDE:
+				value = x / y
+			case OP_INT_DIVIDE:
+				value = math.Floor(x / y)
+			case OP_MODULO:
+				value = modulo(x, y)
+			case OP_POWER:
+				value = math.Pow(x, y)
+			}
+			m.stack[len(m.stack)-1] = value
+		case OP_NOT:
+			m.stack[len(m.stack)-1] = !isTruthy(m.peek(0))
+		case OP_NEGATE:
+			value, err := unary(t, m.peek(0))
+			if err != nil {
+				return nil, err
+			}
+			m.stack[len(m.stack)-1] = value
+		case OP_PRINT:
+			fmt.Fprintln(m.inter.stdout, stringify(m.pop()))
+		case OP_JUMP:
+			offset := readShort()
+			frame.ip += offset
+		case OP_JUMP_IF_FALSE:
+			offset := readShort()
+			if !isTruthy(m.peek(0)) {
+				frame.ip += offset
+			}
+		case OP_LOOP:
+			offset := readShort()
+			frame.ip -= offset
+		case OP_CALL:
+			argCount := int(code[frame.ip])
+			frame.ip += 1
+			err := m.call(argCount, t)
+			if err != nil {
+				return nil, err
+			}
+			reload()
+		case OP_INVOKE:
+			name := frame.closure.fn.chunk.tokens[frame.ip]
+			frame.ip += 2
+			argCount := int(code[frame.ip])
+			frame.ip += 1
+			err := m.invoke(name, argCount, t)
+			if err != nil {
+				return nil, err
+			}
+			reload()
+		case OP_CLOSURE:
+			fn := readConstant().(*function)
+			c := &closure{fn: fn, upvalues: make([]*upvalue, fn.upvalueCount), globals: frame.closure.globals, m: m}
+			for i := range c.upvalues {
+				isLocal, index := code[frame.ip], int(code[frame.ip+1])
+				frame.ip += 2
+				if isLocal == 1 {
+					c.upvalues[i] = m.captureUpvalue(frame.base + index)
+				} else {
+					c.upvalues[i] = frame.closure.upvalues[index]
+				}
+			}
+			m.push(c)
+		case OP_CLOSE_UPVALUE:
+			m.closeUpvalues(len(m.stack) - 1)
+			m.stack = m.stack[:len(m.stack)-1]
+		case OP_RETURN:
+			result := m.pop()
+			m.closeUpvalues(frame.base)
+			for len(m.handlers) > 0 && m.handlers[len(m.handlers)-1].frame >= len(m.frames)-1 {
+				m.handlers = m.handlers[:len(m.handlers)-1]
+			}
+
+			m.stack = m.stack[:frame.base]
+			m.frames = m.frames[:len(m.frames)-1]
+			if len(m.frames) == base {
+				return result, nil
+			}
+
+			m.inter.frames = m.inter.frames[:len(m.inter.frames)-1]
+			m.push(result)
+			reload()
+		case OP_CLASS:
+			name := readConstant().(string)
+			m.push(newLoxClass(name, nil, map[string]classMethod{}))
+		case OP_INHERIT:
+			class := m.pop().(*LoxClass)
+			superclass, ok := m.peek(0).(*LoxClass)
+			if !ok {
+				return nil, &RuntimeError{t: t, message: "Superclass must be a class."}
+			}
+			class.superclass = superclass
+		case OP_METHOD:
+			name := readConstant().(string)
+			method := m.pop().(*closure)
+			m.peek(0).(*LoxClass).methods[name] = method
+		case OP_LIST:
+			count := readShort()
+			elements := append([]any{}, m.stack[len(m.stack)-count:]...)
+			m.stack = m.stack[:len(m.stack)-count]
+			m.push(newLoxList(elements))
+		case OP_MAP:
+			count := readShort()
+			entries := m.stack[len(m.stack)-2*count:]
+			dict := newLoxMap()
+			for i := 0; i < len(entries); i += 2 {
+				err := dict.setIndex(t, entries[i], entries[i+1])
+				if err != nil {
+					return nil, err
+				}
+			}
+			m.stack = m.stack[:len(m.stack)-2*count]
+			m.push(dict)
+		case OP_ITER:
+			next, err := m.inter.iterate(t, m.pop())
+			if err != nil {
+				return nil, err
+			}
+			reload()
+			m.push(next)
+		case OP_FOR_NEXT:
+			offset := readShort()
+			value, ok, err := m.peek(0).(iterator)()
+			if err != nil {
+				return nil, err
+			}
+			reload()
+			if ok {
+				m.push(value)
+			} else {
+				frame.ip += offset
+			}
+		case OP_THROW:
+			return nil, &ThrowError{t: t, value: m.pop(), trace: append([]StackFrame{}, m.inter.frames...)}
+		case OP_TRY_CATCH, OP_TRY_FINALLY:
+			offset := readShort()
+			m.handlers = append(m.handlers, handler{
+				catch:  op == OP_TRY_CATCH,
+				frame:  len(m.frames) - 1,
+				sp:     len(m.stack),
+				target: frame.ip + offset,
+				calls:  len(m.inter.frames),
+			})
+		case OP_POP_HANDLER:
+			m.handlers = m.handlers[:len(m.handlers)-1]
+		case OP_RETHROW:
+			return nil, m.pop().(*pendingError).err
+		case OP_IMPORT:
+			stmt := readConstant().(*Import[any])
+			module, err := m.inter.importModule(stmt.path, m.runModule)
+			if err != nil {
+				return nil, err
+			}
+			err = module.bind(stmt, frame.closure.globals)
+			if err != nil {
+				return nil, err
+			}
+			// The module ran on the same stack.
+			reload()
+		default:
+			return nil, &RuntimeError{t: t, message: fmt.Sprintf("Unknown opcode %v.", op)}
+		}
+	}
+}
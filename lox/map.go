@@ -0,0 +1,137 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+// LoxMap is a dictionary whose keys are compared as isEqual compares values.
+// Iteration follows insertion order.
+type LoxMap struct {
+	keys    []any
+	entries map[any]any
+}
+
+func newLoxMap() *LoxMap {
+	return &LoxMap{entries: map[any]any{}}
+}
+
+func (m *LoxMap) String() string {
+	return formatValue(m, map[any]bool{})
+}
+
+func (m *LoxMap) getIndex(bracket *token, key any) (any, error) {
+	err := checkKey(bracket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := m.entries[key]
+	if !ok {
+		return nil, &RuntimeError{t: bracket, message: fmt.Sprintf("Undefined key %v.", formatValue(key, map[any]bool{m: true}))}
+	}
+
+	return value, nil
+}
+
+func (m *LoxMap) setIndex(bracket *token, key any, value any) error {
+	err := checkKey(bracket, key)
+	if err != nil {
+		return err
+	}
+
+	m.put(key, value)
+	return nil
+}
+
+func (m *LoxMap) put(key any, value any) {
+	_, ok := m.entries[key]
+	if !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+func (m *LoxMap) remove(key any) (any, bool) {
+	value, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	delete(m.entries, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+
+	return value, true
+}
+
+func (m *LoxMap) get(name *token) (any, error) {
+	switch name.lexeme {
+	case "len":
+		return newBuiltinMethod("len", 0, func(v *interpreter, arguments []any) (any, error) {
+			return float64(len(m.keys)), nil
+		}), nil
+	case "keys":
+		return newBuiltinMethod("keys", 0, func(v *interpreter, arguments []any) (any, error) {
+			return newLoxList(append([]any{}, m.keys...)), nil
+		}), nil
+	case "values":
+		return newBuiltinMethod("values", 0, func(v *interpreter, arguments []any) (any, error) {
+			values := make([]any, len(m.keys))
+			for i, key := range m.keys {
+				values[i] = m.entries[key]
+			}
+			return newLoxList(values), nil
+		}), nil
+	case "has":
+		return newBuiltinMethod("has", 1, func(v *interpreter, arguments []any) (any, error) {
+			if !isHashable(arguments[0]) {
+				return false, nil
+			}
+			_, ok := m.entries[arguments[0]]
+			return ok, nil
+		}), nil
+	case "remove":
+		return newBuiltinMethod("remove", 1, func(v *interpreter, arguments []any) (any, error) {
+			if !isHashable(arguments[0]) {
+				return nil, nil
+			}
+			value, _ := m.remove(arguments[0])
+			return value, nil
+		}), nil
+	}
+
+	return nil, &RuntimeError{t: name, message: fmt.Sprintf("Undefined property '%v'.", name.lexeme)}
+}
+
+// isHashable reports whether value can be a map key: only values that isEqual
+// compares by value rather than identity qualify. NaN is excluded because it
+// isn't equal to itself, so its entry could never be found again.
+func isHashable(value any) bool {
+	switch value := value.(type) {
+	case nil, bool, string:
+		return true
+	case float64:
+		return !math.IsNaN(value)
+	}
+
+	return false
+}
+
+// checkKey returns an error at bracket if key can't be a map key.
+func checkKey(bracket *token, key any) error {
+	if isHashable(key) {
+		return nil
+	}
+
+	number, ok := key.(float64)
+	if ok && math.IsNaN(number) {
+		return &RuntimeError{t: bracket, message: "Map keys can't be NaN."}
+	}
+
+	return &RuntimeError{t: bracket, message: "Map keys must be strings, numbers, booleans or nil."}
+}
@@ -0,0 +1,107 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+// newMath returns the Math namespace.
+func newMath() *namespace {
+	return newNamespace("Math", map[string]any{
+		"PI":    math.Pi,
+		"E":     math.E,
+		"floor": mathFunction("floor", math.Floor),
+		"ceil":  mathFunction("ceil", math.Ceil),
+		"round": mathFunction("round", math.Round),
+		"abs":   mathFunction("abs", math.Abs),
+		"sqrt":  mathFunction("sqrt", math.Sqrt),
+		"sin":   mathFunction("sin", math.Sin),
+		"cos":   mathFunction("cos", math.Cos),
+		"tan":   mathFunction("tan", math.Tan),
+		"log":   mathFunction("log", math.Log),
+		"exp":   mathFunction("exp", math.Exp),
+		"pow": newNativeFunction("pow", 2, func(arguments []Value) (Value, error) {
+			x, err := numberArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			y, err := numberArgument(arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			return math.Pow(x, y), nil
+		}),
+		"min": newNativeFunction("min", Variadic, func(arguments []Value) (Value, error) {
+			return extremum(arguments, math.Min)
+		}),
+		"max": newNativeFunction("max", Variadic, func(arguments []Value) (Value, error) {
+			return extremum(arguments, math.Max)
+		}),
+		"isNaN": newNativeFunction("isNaN", 1, func(arguments []Value) (Value, error) {
+			x, err := numberArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return math.IsNaN(x), nil
+		}),
+		"isInf": newNativeFunction("isInf", 1, func(arguments []Value) (Value, error) {
+			x, err := numberArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return math.IsInf(x, 0), nil
+		}),
+	})
+}
+
+// mathFunction wraps a function of one number.
+func mathFunction(name string, fn func(float64) float64) *nativeFunction {
+	return newNativeFunction(name, 1, func(arguments []Value) (Value, error) {
+		x, err := numberArgument(arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(x), nil
+	})
+}
+
+// extremum reduces one or more numbers with fn.
+func extremum(arguments []Value, fn func(float64, float64) float64) (Value, error) {
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("Expected at least 1 argument but got 0.")
+	}
+
+	result, err := numberArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(arguments); i++ {
+		x, err := numberArgument(arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		result = fn(result, x)
+	}
+
+	return result, nil
+}
+
+func numberArgument(arguments []Value, i int) (float64, error) {
+	number, ok := arguments[i].(float64)
+	if !ok {
+		return 0, fmt.Errorf("Argument %v must be a number but got %v.", i+1, typeName(arguments[i]))
+	}
+
+	return number, nil
+}
+
+// modulo returns the remainder of floored division, which takes the sign of
+// the divisor so that a == (a ~/ b) * b + a % b.
+func modulo(a, b float64) float64 {
+	r := math.Mod(a, b)
+	if r != 0 && (r < 0) != (b < 0) {
+		r += b
+	}
+
+	return r
+}
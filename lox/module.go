@@ -0,0 +1,224 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// module is an imported file. Its exports are the variables, functions and
+// classes declared at its top level.
+type module struct {
+	env     *Environment
+	exports []string
+}
+
+// ModuleError reports an imported module that could not be compiled. It wraps
+// the scan, parse and resolver errors found in the module.
+type ModuleError struct {
+	t    *token
+	path string
+	err  error
+}
+
+func (err *ModuleError) Error() string {
+	return fmt.Sprintf("%v\n[%v] Error: Could not compile module '%v'.%v", err.err, err.t.position(), err.path, err.t.snippet())
+}
+
+func (err *ModuleError) Unwrap() error {
+	return err.err
+}
+
+func (v *interpreter) visitImportStmt(stmt *Import[any]) error {
+	m, err := v.importModule(stmt.path, v.runModule)
+	if err != nil {
+		return err
+	}
+
+	return m.bind(stmt, v.globals)
+}
+
+// bind defines the names imported by stmt in globals.
+func (m *module) bind(stmt *Import[any], globals *Environment) error {
+	if stmt.names == nil {
+		for _, name := range m.exports {
+			globals.values[name] = m.env.values[name]
+		}
+		return nil
+	}
+
+	for _, name := range stmt.names {
+		if !slices.Contains(m.exports, name.lexeme) {
+			return &RuntimeError{t: name, message: fmt.Sprintf("Module '%v' has no export '%v'.", stmt.path.literal, name.lexeme)}
+		}
+		globals.values[name.lexeme] = m.env.values[name.lexeme]
+	}
+
+	return nil
+}
+
+// runModule executes the statements of a module with env as its global
+// scope.
+func (v *interpreter) runModule(statements []Stmt[any], env *Environment) error {
+	previousGlobals, previousEnv := v.globals, v.env
+	v.globals, v.env = env, env
+	defer func() {
+		v.globals, v.env = previousGlobals, previousEnv
+	}()
+
+	for _, s := range statements {
+		err := v.execute(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importModule returns the module named by path, using run to execute it in
+// a global scope of its own the first time it is imported.
+func (v *interpreter) importModule(path *token, run func(statements []Stmt[any], env *Environment) error) (*module, error) {
+	name := path.literal.(string)
+	file, ok := v.findModule(name)
+	if !ok {
+		return nil, &RuntimeError{t: path, message: fmt.Sprintf("Can't find module '%v'.", name)}
+	}
+
+	i := slices.Index(v.files, file)
+	if i >= 0 {
+		cycle := []string{}
+		for _, f := range append(v.files[i:], file) {
+			cycle = append(cycle, filepath.Base(f))
+		}
+		return nil, &RuntimeError{t: path, message: fmt.Sprintf("Import cycle: %v.", strings.Join(cycle, " -> "))}
+	}
+
+	m, ok := v.modules[file]
+	if ok {
+		return m, nil
+	}
+
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, &RuntimeError{t: path, message: fmt.Sprintf("Can't read module '%v'.", name)}
+	}
+
+	statements, err := v.compileModule(string(source), displayPath(file))
+	if err != nil {
+		return nil, &ModuleError{t: path, path: name, err: err}
+	}
+
+	m = &module{env: v.newGlobals(), exports: exports(statements)}
+
+	// Running the module's top-level code appears in stack traces like a
+	// call made by the import.
+	err = v.pushFrame("<module>", path)
+	if err != nil {
+		return nil, err
+	}
+	v.files = append(v.files, file)
+	err = run(statements, m.env)
+	v.files = v.files[:len(v.files)-1]
+	if err != nil {
+		var re *RuntimeError
+		if errors.As(err, &re) && re.trace == nil {
+			re.trace = append([]StackFrame{}, v.frames...)
+		}
+	}
+	v.frames = v.frames[:len(v.frames)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	v.modules[file] = m
+	return m, nil
+}
+
+// compileModule compiles the source of the module file, the path shown in
+// its errors.
+func (v *interpreter) compileModule(source string, file string) ([]Stmt[any], error) {
+	scanner := newScanner(source)
+	scanner.file = file
+	tokens, scanErr := scanner.scanTokens()
+
+	parser := newParser[any](tokens)
+	statements, err := parser.parse()
+	if err != nil {
+		return nil, errors.Join(scanErr, err)
+	}
+
+	err = newResolver(v).resolve(statements)
+	if err != nil || scanErr != nil {
+		return nil, errors.Join(scanErr, err)
+	}
+
+	return statements, nil
+}
+
+// displayPath returns the path of file relative to the working directory,
+// or the absolute path of a file outside it.
+func displayPath(file string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+
+	rel, err := filepath.Rel(wd, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+
+	return rel
+}
+
+// findModule returns the absolute path of the module name, looking first
+// beside the importing file and then in each directory of the search path.
+// The .lox extension may be left out.
+func (v *interpreter) findModule(name string) (string, bool) {
+	if filepath.Ext(name) == "" {
+		name += ".lox"
+	}
+
+	dirs := []string{""}
+	if !filepath.IsAbs(name) {
+		dir := "."
+		if len(v.files) > 0 {
+			dir = filepath.Dir(v.files[len(v.files)-1])
+		}
+		dirs = append([]string{dir}, v.searchPath...)
+	}
+
+	for _, dir := range dirs {
+		path, err := filepath.Abs(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+func exports(statements []Stmt[any]) []string {
+	names := []string{}
+	for _, s := range statements {
+		switch s := s.(type) {
+		case *Var[any]:
+			names = append(names, s.name.lexeme)
+		case *Function[any]:
+			names = append(names, s.name.lexeme)
+		case *Class[any]:
+			names = append(names, s.name.lexeme)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,60 @@
+package lox
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModuleErrorsNameTheFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.lox":  "import { bad } from \"mod\";\n\nbad();\n",
+		"mod.lox":   "fun bad() {\n  var x = nil;\n  return x.y;\n}\n",
+		"cycle.lox": "import \"other\";\n",
+		"other.lox": "import \"cycle\";\n",
+	}
+	for name, source := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mod := filepath.Join(dir, "mod.lox")
+	other := filepath.Join(dir, "other.lox")
+
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{"main.lox", []string{
+			"  line 3, in <script>\n",
+			"  line 3 of " + mod + ", in bad\n",
+			"[line 3:12 of " + mod + "] Runtime Error: Only instances have properties.",
+		}},
+		{"cycle.lox", []string{
+			"  line 1 of " + other + ", in <module>\n",
+			"[line 1:8 of " + other + "] Runtime Error: Import cycle: cycle.lox -> other.lox -> cycle.lox.",
+		}},
+	}
+
+	for _, backend := range []Backend{TreeWalker, Bytecode} {
+		for _, test := range tests {
+			vm := New()
+			vm.SetBackend(backend)
+			vm.SetOutput(io.Discard)
+			err := vm.RunFile(filepath.Join(dir, test.script))
+			if err == nil {
+				t.Fatalf("%v ran without an error", test.script)
+			}
+
+			for _, want := range test.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("%v on backend %v: %q doesn't contain %q", test.script, backend, err, want)
+				}
+			}
+		}
+	}
+}
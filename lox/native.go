@@ -0,0 +1,377 @@
+package lox
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+)
+
+// NativeFunc is a Go function that can be called from Lox. An error it
+// returns is reported as a runtime error at the call site.
+type NativeFunc func(arguments []Value) (Value, error)
+
+// Variadic is the arity of a native function that accepts any number of
+// arguments.
+const Variadic = -1
+
+type nativeFunction struct {
+	name   string
+	params int
+	fn     NativeFunc
+}
+
+func newNativeFunction(name string, arity int, fn NativeFunc) *nativeFunction {
+	return &nativeFunction{name: name, params: arity, fn: fn}
+}
+
+func (f *nativeFunction) arity() int {
+	return f.params
+}
+
+func (f *nativeFunction) call(v *interpreter, arguments []any) (result any, err error) {
+	// A panic in Go code is reported like any other error rather than
+	// ending the host program.
+	defer func() {
+		r := recover()
+		if r != nil {
+			result, err = nil, fmt.Errorf("%v panicked: %v", f.name, r)
+		}
+	}()
+
+	return f.fn(arguments)
+}
+
+func (f *nativeFunction) String() string {
+	return fmt.Sprintf("<native fn %v>", f.name)
+}
+
+// builtinMethod is a method of a built-in type, such as a list, bound to its
+// receiver. Unlike a NativeFunc it may call back into the interpreter.
+type builtinMethod struct {
+	name   string
+	params int
+	fn     func(v *interpreter, arguments []any) (any, error)
+}
+
+func newBuiltinMethod(name string, arity int, fn func(v *interpreter, arguments []any) (any, error)) *builtinMethod {
+	return &builtinMethod{name: name, params: arity, fn: fn}
+}
+
+func (m *builtinMethod) arity() int {
+	return m.params
+}
+
+func (m *builtinMethod) call(v *interpreter, arguments []any) (any, error) {
+	return m.fn(v, arguments)
+}
+
+func (m *builtinMethod) String() string {
+	return fmt.Sprintf("<native fn %v>", m.name)
+}
+
+// namespace groups related natives and constants under one global name,
+// such as Math.floor. Its members are read-only.
+type namespace struct {
+	name    string
+	members map[string]any
+}
+
+func newNamespace(name string, members map[string]any) *namespace {
+	return &namespace{name: name, members: members}
+}
+
+func (n *namespace) GetProperty(name string) (Value, bool) {
+	value, ok := n.members[name]
+	return value, ok
+}
+
+func (n *namespace) SetProperty(name string, value Value) error {
+	return fmt.Errorf("Can't assign to members of %v.", n.name)
+}
+
+func (n *namespace) Method(name string) (Callable, bool) {
+	return nil, false
+}
+
+func (n *namespace) String() string {
+	return fmt.Sprintf("<namespace %v>", n.name)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// bindNative wraps an ordinary Go function so that it can be called from Lox.
+// Lox numbers are converted to any Go numeric parameter type, and the
+// function may return nothing, a value, an error, or a value and an error.
+func bindNative(name string, fn any) (*nativeFunction, error) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return nil, fmt.Errorf("cannot bind %v: %v is not a function", name, ft)
+	}
+
+	switch ft.NumOut() {
+	case 0, 1:
+	case 2:
+		if ft.Out(1) != errorType {
+			return nil, fmt.Errorf("cannot bind %v: second result must be an error", name)
+		}
+	default:
+		return nil, fmt.Errorf("cannot bind %v: too many results", name)
+	}
+
+	arity := ft.NumIn()
+	if ft.IsVariadic() {
+		arity = Variadic
+	}
+
+	return newNativeFunction(name, arity, func(arguments []Value) (Value, error) {
+		in, err := toGoArguments(ft, arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		return fromGoResults(fv.Call(in))
+	}), nil
+}
+
+// bindNativeOrError binds fn like bindNative, except that a function which
+// can't be bound is replaced by one that reports why when it is called.
+func bindNativeOrError(name string, fn any) *nativeFunction {
+	native, err := bindNative(name, fn)
+	if err != nil {
+		return newNativeFunction(name, Variadic, func(arguments []Value) (Value, error) {
+			return nil, err
+		})
+	}
+
+	return native
+}
+
+func toGoArguments(ft reflect.Type, arguments []Value) ([]reflect.Value, error) {
+	fixed := ft.NumIn()
+	if ft.IsVariadic() {
+		fixed -= 1
+		if len(arguments) < fixed {
+			return nil, fmt.Errorf("Expected at least %v arguments but got %v.", fixed, len(arguments))
+		}
+	}
+
+	in := make([]reflect.Value, len(arguments))
+	for i, argument := range arguments {
+		var t reflect.Type
+		if i < fixed {
+			t = ft.In(i)
+		} else {
+			t = ft.In(fixed).Elem()
+		}
+
+		value, err := toGo(argument, t)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %v %v", i+1, err)
+		}
+		in[i] = value
+	}
+
+	return in, nil
+}
+
+// toGo converts a Lox value to a Go value of type t.
+func toGo(value Value, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("must be %v but got nil.", describeType(t))
+	}
+
+	host, ok := value.(*hostStruct)
+	if ok && host.rv.Type().AssignableTo(t) {
+		return host.rv, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Type().AssignableTo(t) {
+		return rv, nil
+	}
+
+	number, ok := value.(float64)
+	if ok {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if number != math.Trunc(number) {
+				return reflect.Value{}, fmt.Errorf("must be an integer but got %v.", stringify(value))
+			}
+			low, high := int64(-1)<<(t.Bits()-1), int64(1)<<(t.Bits()-1)-1
+			if number < float64(low) || number >= -float64(low) {
+				return reflect.Value{}, fmt.Errorf("must be an integer from %v to %v but got %v.", low, high, stringify(value))
+			}
+			return reflect.ValueOf(int64(number)).Convert(t), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if number != math.Trunc(number) {
+				return reflect.Value{}, fmt.Errorf("must be an integer but got %v.", stringify(value))
+			}
+			high := ^uint64(0) >> (64 - t.Bits())
+			if number < 0 || number >= math.Ldexp(1, t.Bits()) {
+				return reflect.Value{}, fmt.Errorf("must be an integer from 0 to %v but got %v.", high, stringify(value))
+			}
+			return reflect.ValueOf(uint64(number)).Convert(t), nil
+		case reflect.Float32:
+			return rv.Convert(t), nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("must be %v but got %v.", describeType(t), typeName(value))
+}
+
+func fromGoResults(out []reflect.Value) (Value, error) {
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		if out[0].Type() == errorType {
+			return nil, toError(out[0])
+		}
+		return fromGo(out[0]), nil
+	default:
+		err := toError(out[1])
+		if err != nil {
+			return nil, err
+		}
+		return fromGo(out[0]), nil
+	}
+}
+
+func toError(rv reflect.Value) error {
+	if rv.IsNil() {
+		return nil
+	}
+	return rv.Interface().(error)
+}
+
+// fromGo converts a Go value to its Lox representation. Slices, arrays and
+// maps are copied into Lox lists and maps, so a script's changes to them are
+// not seen by the host, and functions are bound as natives.
+func fromGo(rv reflect.Value) Value {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return fromGo(rv.Elem())
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		elements := make([]any, rv.Len())
+		for i := range elements {
+			elements[i] = fromGo(rv.Index(i))
+		}
+		return newLoxList(elements)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		return fromGoMap(rv)
+	case reflect.Func:
+		if rv.IsNil() {
+			return nil
+		}
+		return bindNativeOrError(rv.Type().String(), rv.Interface())
+	case reflect.Invalid:
+		return nil
+	}
+
+	if isHostStruct(rv) {
+		return &hostStruct{rv: rv}
+	}
+
+	return rv.Interface()
+}
+
+// fromGoMap converts a Go map to a Lox map, ordered by key. A key that
+// can't be a Lox map key, such as a struct, is replaced by its string form.
+func fromGoMap(rv reflect.Value) *LoxMap {
+	m := newLoxMap()
+	iter := rv.MapRange()
+	for iter.Next() {
+		key := fromGo(iter.Key())
+		if !isHashable(key) {
+			key = stringify(key)
+		}
+		m.put(key, fromGo(iter.Value()))
+	}
+
+	slices.SortFunc(m.keys, func(a, b any) int {
+		x, xOk := a.(float64)
+		y, yOk := b.(float64)
+		if xOk && yOk {
+			return cmp.Compare(x, y)
+		}
+		return cmp.Compare(stringify(a), stringify(b))
+	})
+	return m
+}
+
+func toLox(value any) Value {
+	return fromGo(reflect.ValueOf(value))
+}
+
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	}
+
+	return t.String()
+}
+
+// typeName describes the type of a Lox value for error messages.
+func typeName(value Value) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case *LoxInstance:
+		return "an instance of " + value.class.name
+	case *LoxList:
+		return "a list"
+	case *LoxMap:
+		return "a map"
+	case *LoxError:
+		return "an error"
+	case LoxCallable, Callable:
+		return "a function"
+	case HostObject:
+		return "an object"
+	}
+
+	return reflect.TypeOf(value).String()
+}
@@ -0,0 +1,246 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// evalOutput runs source in vm and returns what it printed.
+func evalOutput(t *testing.T, vm *VM, source string) string {
+	t.Helper()
+	var out bytes.Buffer
+	vm.SetOutput(&out)
+	err := vm.Eval(source)
+	if err != nil {
+		t.Fatalf("Eval(%q) failed: %v", source, err)
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// runtimeErrorMessage runs source in vm and returns the message of the
+// runtime error it should fail with.
+func runtimeErrorMessage(t *testing.T, vm *VM, source string) string {
+	t.Helper()
+	err := vm.Eval(source)
+	var re *RuntimeError
+	if !errors.As(err, &re) {
+		t.Fatalf("Eval(%q) returned %v, want a runtime error", source, err)
+	}
+	return re.message
+}
+
+// panicker is a host Callable that panics when called.
+type panicker struct{}
+
+func (panicker) Arity() int { return 0 }
+
+func (panicker) Call(arguments []Value) (Value, error) {
+	panic("host failure")
+}
+
+func TestDefine(t *testing.T) {
+	vm := New()
+	vm.Define("sum", Variadic, func(arguments []Value) (Value, error) {
+		total := 0.0
+		for _, argument := range arguments {
+			total += argument.(float64)
+		}
+		return total, nil
+	})
+	vm.Define("fail", 0, func(arguments []Value) (Value, error) {
+		return nil, errors.New("Failed on purpose.")
+	})
+
+	got := evalOutput(t, vm, "print sum(1, 2, 3); print sum;")
+	if got != "6\n<native fn sum>" {
+		t.Errorf("got %q", got)
+	}
+
+	vm.Define("bad", 0, func(arguments []Value) (Value, error) {
+		var counts map[string]int
+		counts["calls"] += 1
+		return nil, nil
+	})
+
+	message := runtimeErrorMessage(t, vm, "fail();")
+	if message != "Failed on purpose." {
+		t.Errorf("got %q", message)
+	}
+
+	message = runtimeErrorMessage(t, vm, "bad();")
+	if message != "bad panicked: assignment to entry in nil map" {
+		t.Errorf("got %q", message)
+	}
+
+	vm.SetGlobal("host", panicker{})
+	message = runtimeErrorMessage(t, vm, "host();")
+	if message != "host panicked: host failure" {
+		t.Errorf("got %q", message)
+	}
+
+	// Natives survive a reset.
+	vm.Reset()
+	got = evalOutput(t, vm, "print sum(4);")
+	if got != "4" {
+		t.Errorf("got %q after Reset", got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	vm := New()
+	register := func(name string, fn any) {
+		t.Helper()
+		err := vm.Register(name, fn)
+		if err != nil {
+			t.Fatalf("Register(%q) failed: %v", name, err)
+		}
+	}
+	register("greet", func(name string, times int) string {
+		return strings.Repeat("hi "+name+" ", times)
+	})
+	register("byte", func(b uint8) uint8 { return b })
+	register("small", func(n int8) int8 { return n })
+	register("half", func(x float32) float32 { return x / 2 })
+	register("check", func(ok bool) error {
+		if !ok {
+			return errors.New("Not ok.")
+		}
+		return nil
+	})
+	register("boom", func() int { panic("oops") })
+
+	got := evalOutput(t, vm, `print greet("lox", 2); print byte(255); print small(-128); print half(3); check(true);`)
+	if got != "hi lox hi lox \n255\n-128\n1.5" {
+		t.Errorf("got %q", got)
+	}
+
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{`greet(1, 2);`, "Argument 1 must be a string but got a number."},
+		{`greet("lox", 1.5);`, "Argument 2 must be an integer but got 1.5."},
+		{`byte(-1);`, "Argument 1 must be an integer from 0 to 255 but got -1."},
+		{`byte(300);`, "Argument 1 must be an integer from 0 to 255 but got 300."},
+		{`small(128);`, "Argument 1 must be an integer from -128 to 127 but got 128."},
+		{`small(nil);`, "Argument 1 must be a number but got nil."},
+		{`check(false);`, "Not ok."},
+		{`boom();`, "boom panicked: oops"},
+	}
+	for _, test := range tests {
+		message := runtimeErrorMessage(t, vm, test.source)
+		if message != test.message {
+			t.Errorf("%v: got %q, want %q", test.source, message, test.message)
+		}
+	}
+}
+
+func TestRegisterRejectsNonFunctions(t *testing.T) {
+	vm := New()
+	err := vm.Register("five", 5)
+	if err == nil {
+		t.Error("Register accepted a number")
+	}
+
+	err = vm.Register("pair", func() (int, int) { return 1, 2 })
+	if err == nil {
+		t.Error("Register accepted a function whose second result isn't an error")
+	}
+}
+
+func TestCall(t *testing.T) {
+	vm := New()
+	evalOutput(t, vm, `fun add(a, b) { return a + b; } class Point { init(x) { this.x = x; } }`)
+
+	add, ok := vm.GetGlobal("add")
+	if !ok {
+		t.Fatal("add is not defined")
+	}
+	result, err := vm.Call(add, 1, 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != 3.5 {
+		t.Errorf("add(1, 2.5) = %v", result)
+	}
+
+	point, _ := vm.GetGlobal("Point")
+	result, err = vm.Call(point, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, ok := result.(*LoxInstance)
+	if !ok || instance.fields["x"] != 7.0 {
+		t.Errorf("Point(7) = %v", result)
+	}
+
+	_, err = vm.Call(add, 1)
+	if err == nil {
+		t.Error("Call accepted too few arguments")
+	}
+	_, err = vm.Call("add")
+	if err == nil {
+		t.Error("Call accepted a string")
+	}
+}
+
+func TestSetGlobal(t *testing.T) {
+	vm := New()
+	vm.SetGlobal("count", 3)
+	vm.SetGlobal("name", "lox")
+
+	got := evalOutput(t, vm, "print count + 1; print name; var result = count * 2;")
+	if got != "4\nlox" {
+		t.Errorf("got %q", got)
+	}
+
+	result, ok := vm.GetGlobal("result")
+	if !ok || result != 6.0 {
+		t.Errorf("result = %v, %v", result, ok)
+	}
+
+	_, ok = vm.GetGlobal("missing")
+	if ok {
+		t.Error("GetGlobal found an undefined variable")
+	}
+}
+
+func TestSetGlobalConvertsContainers(t *testing.T) {
+	vm := New()
+	vm.SetGlobal("xs", []int{1, 2})
+	vm.SetGlobal("ages", map[string]int{"bob": 40, "ada": 36})
+	vm.SetGlobal("square", func(n int) int { return n * n })
+	vm.SetGlobal("pair", func() (int, int) { return 1, 2 })
+
+	got := evalOutput(t, vm, `
+print xs == xs;
+xs.push(3);
+print xs;
+print ages;
+print ages["ada"];
+print square(4);
+`)
+	if got != "true\n[1, 2, 3]\n{\"ada\": 36, \"bob\": 40}\n36\n16" {
+		t.Errorf("got %q", got)
+	}
+
+	message := runtimeErrorMessage(t, vm, "pair();")
+	if message != "cannot bind func() (int, int): second result must be an error" {
+		t.Errorf("got %q", message)
+	}
+}
+
+func TestIsEqualUncomparable(t *testing.T) {
+	xs := []int{1}
+	if isEqual(xs, xs) {
+		t.Error("isEqual compared slices as equal")
+	}
+	if isEqual(xs, 1.0) {
+		t.Error("isEqual compared a slice and a number as equal")
+	}
+	if !isEqual(1.0, 1.0) || isEqual(nil, false) {
+		t.Error("isEqual changed for Lox values")
+	}
+}
@@ -1,6 +1,7 @@
-package main
+package lox
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -16,13 +17,17 @@ func (err *ParseError) Error() string {
 	} else {
 		where = " at " + err.t.lexeme
 	}
-	return fmt.Sprintf("[line %v] Error%s: %v\n", err.t.line, where, err.message)
+	return fmt.Sprintf("[%v] Error%s: %v%v", err.t.position(), where, err.message, err.t.snippet())
 }
 
 type Parser[T any] struct {
 	tokens  []*token
 	current int
 	errors  []*ParseError
+
+	// repl allows the final statement to be a bare expression without a
+	// semicolon, which is parsed as a print statement so its value is echoed.
+	repl bool
 }
 
 func newParser[T any](tokens []*token) *Parser[T] {
@@ -32,40 +37,61 @@ func newParser[T any](tokens []*token) *Parser[T] {
 	}
 }
 
+// parse returns every statement it could parse. When there are syntax errors
+// it recovers at the next statement and returns all of them joined together.
 func (p *Parser[T]) parse() ([]Stmt[T], error) {
 	statements := []Stmt[T]{}
 	for !p.isAtEnd() {
-		stmt, err := p.declaration()
-		if err != nil {
-			return nil, err
+		stmt := p.declaration()
+		if stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+
+	if len(p.errors) > 0 {
+		errs := make([]error, len(p.errors))
+		for i, err := range p.errors {
+			errs[i] = err
 		}
-		statements = append(statements, stmt)
+		return statements, errors.Join(errs...)
 	}
 
 	return statements, nil
 }
 
-func (p *Parser[T]) declaration() (Stmt[T], error) {
+// declaration returns nil if the declaration has a syntax error, which has
+// been recorded, after skipping to the start of the next statement.
+func (p *Parser[T]) declaration() Stmt[T] {
+	var stmt Stmt[T]
+	var err error
 	if p.match(CLASS) {
-		return p.classDeclaration()
-	}
-	if p.match(FUN) {
-		return p.function("function")
-	}
-	if p.match(VAR) {
-		return p.varDeclaration()
+		stmt, err = p.classDeclaration()
+	} else if p.check(FUN) && p.checkAhead(1, IDENTIFIER) {
+		p.advance()
+		stmt, err = p.function("function")
+	} else if p.match(VAR) {
+		stmt, err = p.varDeclaration()
+	} else if p.match(IMPORT) {
+		stmt, err = p.importDeclaration()
+	} else {
+		stmt, err = p.statement()
 	}
 
-	stmt, err := p.statement()
 	if err != nil {
 		p.synchronize()
-		return nil, err
+		return nil
 	}
 
-	return stmt, nil
+	return stmt
 }
 
 func (p *Parser[T]) statement() (Stmt[T], error) {
+	if p.match(BREAK) {
+		return p.breakStatement()
+	}
+	if p.match(CONTINUE) {
+		return p.continueStatement()
+	}
 	if p.match(FOR) {
 		return p.forStatement()
 	}
@@ -78,6 +104,12 @@ func (p *Parser[T]) statement() (Stmt[T], error) {
 	if p.match(RETURN) {
 		return p.returnStatement()
 	}
+	if p.match(THROW) {
+		return p.throwStatement()
+	}
+	if p.match(TRY) {
+		return p.tryStatement()
+	}
 	if p.match(WHILE) {
 		return p.whileStatement()
 	}
@@ -88,6 +120,26 @@ func (p *Parser[T]) statement() (Stmt[T], error) {
 	return p.expressionStatement()
 }
 
+func (p *Parser[T]) breakStatement() (Stmt[T], error) {
+	keyword := p.previous()
+	_, err := p.consume(SEMICOLON, "Expect ';' after 'break'.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Break[T]{keyword: keyword}, nil
+}
+
+func (p *Parser[T]) continueStatement() (Stmt[T], error) {
+	keyword := p.previous()
+	_, err := p.consume(SEMICOLON, "Expect ';' after 'continue'.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Continue[T]{keyword: keyword}, nil
+}
+
 func (p *Parser[T]) returnStatement() (Stmt[T], error) {
 	var err error
 	var value Expr[T] = nil
@@ -99,7 +151,11 @@ func (p *Parser[T]) returnStatement() (Stmt[T], error) {
 		}
 	}
 
-	p.consume(SEMICOLON, "Expect ';' after return value.")
+	_, err = p.consume(SEMICOLON, "Expect ';' after return value.")
+	if err != nil {
+		return nil, err
+	}
+
 	return &Return[T]{keyword, value}, nil
 }
 
@@ -114,6 +170,36 @@ func (p *Parser[T]) function(kind string) (*Function[T], error) {
 		return nil, err
 	}
 
+	return p.functionBody(name, kind)
+}
+
+// functionBody parses the parameters, following the opening '(', and the
+// body of a function.
+func (p *Parser[T]) functionBody(name *token, kind string) (*Function[T], error) {
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Function[T]{
+		name:   name,
+		params: parameters,
+		body:   body.statements,
+	}, nil
+}
+
+// parameters parses a parameter list up to and including the closing ')'.
+func (p *Parser[T]) parameters() ([]*token, error) {
 	parameters := []*token{}
 	if !p.check(RIGHT_PAREN) {
 		for {
@@ -132,26 +218,12 @@ func (p *Parser[T]) function(kind string) (*Function[T], error) {
 			}
 		}
 	}
-	_, err = p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = p.consume(LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	_, err := p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := p.block()
-	if err != nil {
-		return nil, err
-	}
-
-	return &Function[T]{
-		name:   name,
-		params: parameters,
-		body:   body.statements,
-	}, nil
+	return parameters, nil
 }
 
 func (p *Parser[T]) forStatement() (Stmt[T], error) {
@@ -160,6 +232,10 @@ func (p *Parser[T]) forStatement() (Stmt[T], error) {
 		return nil, err
 	}
 
+	if p.check(VAR) && p.checkAhead(1, IDENTIFIER) && p.checkAhead(2, IN) {
+		return p.forInStatement()
+	}
+
 	var initializer Stmt[T]
 	if p.match(SEMICOLON) {
 		initializer = nil
@@ -202,19 +278,12 @@ func (p *Parser[T]) forStatement() (Stmt[T], error) {
 		return nil, err
 	}
 
-	if increment != nil {
-		body = &Block[T]{
-			statements: []Stmt[T]{
-				body,
-				&Expression[T]{increment},
-			},
-		}
-	}
-
 	if condition == nil {
 		condition = &Literal[T]{true}
 	}
-	body = &While[T]{condition, body}
+	// The increment is kept apart from the body so that it still runs when
+	// the body continues.
+	body = &While[T]{condition, body, increment}
 
 	if initializer != nil {
 		body = &Block[T]{statements: []Stmt[T]{initializer, body}}
@@ -223,6 +292,102 @@ func (p *Parser[T]) forStatement() (Stmt[T], error) {
 	return body, nil
 }
 
+func (p *Parser[T]) forInStatement() (Stmt[T], error) {
+	p.advance()
+	name := p.advance()
+	keyword := p.advance()
+
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(RIGHT_PAREN, "Expect ')' after for clauses.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForIn[T]{name: name, keyword: keyword, iterable: iterable, body: body}, nil
+}
+
+func (p *Parser[T]) throwStatement() (Stmt[T], error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(SEMICOLON, "Expect ';' after thrown value.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Throw[T]{keyword: keyword, value: value}, nil
+}
+
+func (p *Parser[T]) tryStatement() (Stmt[T], error) {
+	_, err := p.consume(LEFT_BRACE, "Expect '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+
+	tryBranch, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &Try[T]{tryBranch: tryBranch}
+	if p.match(CATCH) {
+		_, err = p.consume(LEFT_PAREN, "Expect '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.catchName, err = p.consume(IDENTIFIER, "Expect exception variable name.")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consume(RIGHT_PAREN, "Expect ')' after exception variable.")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consume(LEFT_BRACE, "Expect '{' before catch body.")
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.catchBranch, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.match(FINALLY) {
+		_, err = p.consume(LEFT_BRACE, "Expect '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.finallyBranch, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.catchBranch == nil && stmt.finallyBranch == nil {
+		return nil, p.error(p.peek(), "Expect 'catch' or 'finally' after try block.")
+	}
+
+	return stmt, nil
+}
+
 func (p *Parser[T]) whileStatement() (Stmt[T], error) {
 	_, err := p.consume(LEFT_PAREN, "Expect '(' after 'while'.")
 	if err != nil {
@@ -283,11 +448,10 @@ func (p *Parser[T]) block() (*Block[T], error) {
 	statements := []Stmt[T]{}
 
 	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
-		s, err := p.declaration()
-		if err != nil {
-			return nil, err
+		s := p.declaration()
+		if s != nil {
+			statements = append(statements, s)
 		}
-		statements = append(statements, s)
 	}
 
 	_, err := p.consume(RIGHT_BRACE, "Expect '}' after block.")
@@ -306,7 +470,10 @@ func (p *Parser[T]) classDeclaration() (Stmt[T], error) {
 
 	var superclass *Variable[T]
 	if p.match(LESS) {
-		p.consume(IDENTIFIER, "Expect superclass name")
+		_, err = p.consume(IDENTIFIER, "Expect superclass name.")
+		if err != nil {
+			return nil, err
+		}
 		superclass = &Variable[T]{name: p.previous()}
 	}
 
@@ -354,6 +521,52 @@ func (p *Parser[T]) varDeclaration() (Stmt[T], error) {
 	return &Var[T]{name: name, initializer: initializer}, nil
 }
 
+// importDeclaration parses either form of import, where "from" is not a
+// reserved word:
+//
+//	import "path";
+//	import { a, b } from "path";
+func (p *Parser[T]) importDeclaration() (Stmt[T], error) {
+	keyword := p.previous()
+
+	var names []*token
+	if p.match(LEFT_BRACE) {
+		for {
+			name, err := p.consume(IDENTIFIER, "Expect name to import.")
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+
+		_, err := p.consume(RIGHT_BRACE, "Expect '}' after imported names.")
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.check(IDENTIFIER) || p.peek().lexeme != "from" {
+			return nil, p.error(p.peek(), "Expect 'from' after imported names.")
+		}
+		p.advance()
+	}
+
+	path, err := p.consume(STRING, "Expect module path.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(SEMICOLON, "Expect ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Import[T]{keyword: keyword, names: names, path: path}, nil
+}
+
 func (p *Parser[T]) printStatement() (Stmt[T], error) {
 	value, err := p.expression()
 	if err != nil {
@@ -374,6 +587,10 @@ func (p *Parser[T]) expressionStatement() (Stmt[T], error) {
 		return nil, err
 	}
 
+	if p.repl && p.isAtEnd() {
+		return &Print[T]{expression: expr}, nil
+	}
+
 	_, err = p.consume(SEMICOLON, "Expect ';' after expression.")
 	if err != nil {
 		return nil, err
@@ -411,6 +628,11 @@ func (p *Parser[T]) assignment() (Expr[T], error) {
 			return &Set[T]{object: get.object, name: get.name, value: value}, nil
 		}
 
+		index, ok := expr.(*Index[T])
+		if ok {
+			return &IndexSet[T]{object: index.object, bracket: index.bracket, index: index.index, value: value}, nil
+		}
+
 		return nil, p.error(equals, "Invalid assignment target.")
 	}
 
@@ -515,7 +737,7 @@ func (p *Parser[T]) factor() (Expr[T], error) {
 		return nil, err
 	}
 
-	for p.match(SLASH, STAR) {
+	for p.match(SLASH, TILDE_SLASH, STAR, PERCENT) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -537,7 +759,27 @@ func (p *Parser[T]) unary() (Expr[T], error) {
 		return &Unary[T]{operator, right}, nil
 	}
 
-	return p.call()
+	return p.power()
+}
+
+// power parses exponentiation, which is right-associative and binds more
+// tightly than a unary operator on its left, so -2 ** 2 is -4.
+func (p *Parser[T]) power() (Expr[T], error) {
+	e, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		e = &Binary[T]{left: e, operator: operator, right: right}
+	}
+
+	return e, nil
 }
 
 func (p *Parser[T]) call() (Expr[T], error) {
@@ -558,6 +800,18 @@ func (p *Parser[T]) call() (Expr[T], error) {
 				return nil, err
 			}
 			expr = &Get[T]{expr, name}
+		} else if p.match(LEFT_BRACKET) {
+			bracket := p.previous()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = p.consume(RIGHT_BRACKET, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &Index[T]{object: expr, bracket: bracket, index: index}
 		} else {
 			break
 		}
@@ -628,10 +882,45 @@ func (p *Parser[T]) primary() (Expr[T], error) {
 		return &This[T]{keyword: p.previous()}, nil
 	}
 
+	if p.match(FUN) {
+		keyword := p.previous()
+		_, err := p.consume(LEFT_PAREN, "Expect '(' after 'fun'.")
+		if err != nil {
+			return nil, err
+		}
+
+		function, err := p.functionBody(nil, "function")
+		if err != nil {
+			return nil, err
+		}
+		return &Lambda[T]{keyword: keyword, function: function}, nil
+	}
+
+	if p.check(IDENTIFIER) && p.checkAhead(1, ARROW) {
+		return p.arrowFunction([]*token{p.advance()})
+	}
+
+	if p.check(LEFT_PAREN) && p.isArrowFunction() {
+		p.advance()
+		parameters, err := p.parameters()
+		if err != nil {
+			return nil, err
+		}
+		return p.arrowFunction(parameters)
+	}
+
 	if p.match(IDENTIFIER) {
 		return &Variable[T]{name: p.previous()}, nil
 	}
 
+	if p.match(LEFT_BRACKET) {
+		return p.list()
+	}
+
+	if p.match(LEFT_BRACE) {
+		return p.dictionary()
+	}
+
 	if p.match(LEFT_PAREN) {
 		e, err := p.expression()
 		if err != nil {
@@ -645,7 +934,113 @@ func (p *Parser[T]) primary() (Expr[T], error) {
 		return &Grouping[T]{expression: e}, nil
 	}
 
-	return nil, p.error(p.previous(), "Expected expression.")
+	return nil, p.error(p.peek(), "Expected expression.")
+}
+
+// isArrowFunction reports whether the tokens from the current '(' are the
+// parameters of an arrow function, such as (a, b) =>.
+func (p *Parser[T]) isArrowFunction() bool {
+	i := 1
+	if !p.checkAhead(i, RIGHT_PAREN) {
+		for p.checkAhead(i, IDENTIFIER) {
+			i += 1
+			if !p.checkAhead(i, COMMA) {
+				break
+			}
+			i += 1
+		}
+	}
+
+	return p.checkAhead(i, RIGHT_PAREN) && p.checkAhead(i+1, ARROW)
+}
+
+// arrowFunction parses the body of an arrow function following its
+// parameters. The body is a block, or an expression whose value is returned.
+func (p *Parser[T]) arrowFunction(parameters []*token) (Expr[T], error) {
+	arrow, err := p.consume(ARROW, "Expect '=>' after parameters.")
+	if err != nil {
+		return nil, err
+	}
+
+	var body []Stmt[T]
+	if p.match(LEFT_BRACE) {
+		block, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		body = block.statements
+	} else {
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		body = []Stmt[T]{&Return[T]{keyword: arrow, value: value}}
+	}
+
+	function := &Function[T]{params: parameters, body: body}
+	return &Lambda[T]{keyword: arrow, function: function}, nil
+}
+
+func (p *Parser[T]) list() (Expr[T], error) {
+	bracket := p.previous()
+	elements := []Expr[T]{}
+	if !p.check(RIGHT_BRACKET) {
+		for {
+			element, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(RIGHT_BRACKET, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &List[T]{bracket: bracket, elements: elements}, nil
+}
+
+func (p *Parser[T]) dictionary() (Expr[T], error) {
+	brace := p.previous()
+	keys := []Expr[T]{}
+	values := []Expr[T]{}
+	if !p.check(RIGHT_BRACE) {
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = p.consume(COLON, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(RIGHT_BRACE, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Map[T]{brace: brace, keys: keys, values: values}, nil
 }
 
 func (p *Parser[T]) synchronize() {
@@ -657,7 +1052,7 @@ func (p *Parser[T]) synchronize() {
 		}
 
 		switch p.peek().tokenType {
-		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN:
+		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN, BREAK, CONTINUE, THROW, TRY, IMPORT:
 			return
 		}
 
@@ -675,7 +1070,11 @@ func (p *Parser[T]) consume(tokenType int, message string) (*token, error) {
 
 func (p *Parser[T]) error(t *token, message string) error {
 	err := &ParseError{t: t, message: message}
-	p.errors = append(p.errors, err)
+	// The scanner has already reported the error in an ERROR token, so only
+	// unwind to the next statement.
+	if t.tokenType != ERROR {
+		p.errors = append(p.errors, err)
+	}
 	return err
 }
 
@@ -697,6 +1096,15 @@ func (p *Parser[T]) check(tokenType int) bool {
 	return p.peek().tokenType == tokenType
 }
 
+// checkAhead reports whether the token offset places past the current one is
+// of the given type.
+func (p *Parser[T]) checkAhead(offset int, tokenType int) bool {
+	if p.current+offset >= len(p.tokens) {
+		return false
+	}
+	return p.tokens[p.current+offset].tokenType == tokenType
+}
+
 func (p *Parser[T]) advance() *token {
 	if !p.isAtEnd() {
 		p.current += 1
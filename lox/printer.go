@@ -1,12 +1,14 @@
-package main
+package lox
 
 import (
 	"fmt"
+	"io"
 	"strings"
 )
 
 type astPrinter struct {
 	indent int
+	out    io.Writer
 }
 
 func (p *astPrinter) visitAssignExpr(e *Assign[string]) (string, error) {
@@ -95,6 +97,21 @@ func (p *astPrinter) visitIfStmt(ifStmt *If[string]) error {
 	return nil
 }
 
+func (p *astPrinter) visitImportStmt(s *Import[string]) error {
+	if s.names == nil {
+		p.println(fmt.Sprintf("(import %q)", s.path.literal))
+		return nil
+	}
+
+	names := make([]string, len(s.names))
+	for i, name := range s.names {
+		names[i] = name.lexeme
+	}
+	p.println(fmt.Sprintf("(import %v from %q)", strings.Join(names, ", "), s.path.literal))
+
+	return nil
+}
+
 func (p *astPrinter) visitPrintStmt(s *Print[string]) error {
 	result, err := p.parenthesize("print", s.expression)
 	if err != nil {
@@ -137,8 +154,73 @@ func (p *astPrinter) visitWhileStmt(whileStmt *While[string]) error {
 	p.println(result)
 	p.indent = p.indent + 2
 	whileStmt.body.accept(p)
+	if whileStmt.increment != nil {
+		result, err = p.parenthesize("increment", whileStmt.increment)
+		if err != nil {
+			return err
+		}
+		p.println(result)
+	}
+	p.indent = p.indent - 2
+
+	return nil
+}
+
+func (p *astPrinter) visitForInStmt(stmt *ForIn[string]) error {
+	result, err := p.parenthesize(fmt.Sprintf("for %v in", stmt.name.lexeme), stmt.iterable)
+	if err != nil {
+		return err
+	}
+
+	p.println(result)
+	p.indent = p.indent + 2
+	stmt.body.accept(p)
+	p.indent = p.indent - 2
+
+	return nil
+}
+
+func (p *astPrinter) visitThrowStmt(s *Throw[string]) error {
+	result, err := p.parenthesize("throw", s.value)
+	if err != nil {
+		return err
+	}
+
+	p.println(result)
+
+	return nil
+}
+
+func (p *astPrinter) visitTryStmt(s *Try[string]) error {
+	p.println("(try)")
+	p.indent = p.indent + 2
+	s.tryBranch.accept(p)
 	p.indent = p.indent - 2
 
+	if s.catchBranch != nil {
+		p.println(fmt.Sprintf("(catch %v)", s.catchName.lexeme))
+		p.indent = p.indent + 2
+		s.catchBranch.accept(p)
+		p.indent = p.indent - 2
+	}
+
+	if s.finallyBranch != nil {
+		p.println("(finally)")
+		p.indent = p.indent + 2
+		s.finallyBranch.accept(p)
+		p.indent = p.indent - 2
+	}
+
+	return nil
+}
+
+func (p *astPrinter) visitBreakStmt(s *Break[string]) error {
+	p.println("(break)")
+	return nil
+}
+
+func (p *astPrinter) visitContinueStmt(s *Continue[string]) error {
+	p.println("(continue)")
 	return nil
 }
 
@@ -199,6 +281,35 @@ func (p *astPrinter) visitCallExpr(e *Call[string]) (string, error) {
 	return p.parenthesize(fmt.Sprintf("call: %v", callee), e.arguments...)
 }
 
+func (p *astPrinter) visitIndexExpr(e *Index[string]) (string, error) {
+	return p.parenthesize("index", e.object, e.index)
+}
+
+func (p *astPrinter) visitIndexSetExpr(e *IndexSet[string]) (string, error) {
+	return p.parenthesize("index =", e.object, e.index, e.value)
+}
+
+func (p *astPrinter) visitLambdaExpr(e *Lambda[string]) (string, error) {
+	params := make([]string, len(e.function.params))
+	for i, p := range e.function.params {
+		params[i] = p.lexeme
+	}
+
+	return fmt.Sprintf("(fun (%v) ...)", strings.Join(params, ", ")), nil
+}
+
+func (p *astPrinter) visitListExpr(e *List[string]) (string, error) {
+	return p.parenthesize("list", e.elements...)
+}
+
+func (p *astPrinter) visitMapExpr(e *Map[string]) (string, error) {
+	entries := []Expr[string]{}
+	for i, key := range e.keys {
+		entries = append(entries, key, e.values[i])
+	}
+	return p.parenthesize("map", entries...)
+}
+
 func (p *astPrinter) visitThisExpr(e *This[string]) (string, error) {
 	return "this", nil
 }
@@ -208,7 +319,7 @@ func (p *astPrinter) visitSuperExpr(e *Super[string]) (string, error) {
 }
 
 func (p *astPrinter) println(s string) {
-	fmt.Printf("%v%s\n", strings.Repeat(" ", p.indent), s)
+	fmt.Fprintf(p.out, "%v%s\n", strings.Repeat(" ", p.indent), s)
 }
 
 func (p *astPrinter) parenthesize(name string, expressions ...Expr[string]) (string, error) {
@@ -1,4 +1,4 @@
-package main
+package lox
 
 import (
 	"container/list"
@@ -24,7 +24,16 @@ type ResolverError struct {
 }
 
 func (err *ResolverError) Error() string {
-	return fmt.Sprintf("[line %v] Resolver Error: %s", err.t.line, err.message)
+	return fmt.Sprintf("[%v] Resolver Error: %s%v", err.t.position(), err.message, err.t.snippet())
+}
+
+// variable is a local declared in a scope being resolved.
+type variable struct {
+	// Whether the variable's initializer has been resolved, after which it
+	// can be read.
+	defined bool
+	// The index of the variable in its scope, in order of declaration.
+	slot int
 }
 
 type resolver struct {
@@ -32,10 +41,11 @@ type resolver struct {
 	scopes      *list.List
 	inFuncType  int
 	inClassType int
+	loopDepth   int
 }
 
 func newResolver(i *interpreter) *resolver {
-	r := &resolver{i, list.New(), FUNC_TYPE_NONE, CLASS_TYPE_NONE}
+	r := &resolver{i, list.New(), FUNC_TYPE_NONE, CLASS_TYPE_NONE, 0}
 
 	return r
 }
@@ -71,6 +81,20 @@ func (r *resolver) visitBlockStmt(stmt *Block[any]) error {
 	return nil
 }
 
+func (r *resolver) visitBreakStmt(stmt *Break[any]) error {
+	if r.loopDepth == 0 {
+		return &ResolverError{t: stmt.keyword, message: "Can't use 'break' outside of a loop."}
+	}
+	return nil
+}
+
+func (r *resolver) visitContinueStmt(stmt *Continue[any]) error {
+	if r.loopDepth == 0 {
+		return &ResolverError{t: stmt.keyword, message: "Can't use 'continue' outside of a loop."}
+	}
+	return nil
+}
+
 func (r *resolver) visitCallExpr(e *Call[any]) (any, error) {
 	_, err := r.resolveExpression(e.callee)
 	if err != nil {
@@ -111,13 +135,13 @@ func (r *resolver) visitClassStmt(stmt *Class[any]) error {
 		}
 
 		r.beginScope()
-		scope := r.scopes.Back().Value.(map[string]bool)
-		scope["super"] = true
+		scope := r.scopes.Back().Value.(map[string]*variable)
+		scope["super"] = &variable{defined: true, slot: 0}
 	}
 
 	r.beginScope()
-	scope := r.scopes.Back().Value.(map[string]bool)
-	scope["this"] = true
+	scope := r.scopes.Back().Value.(map[string]*variable)
+	scope["this"] = &variable{defined: true, slot: 0}
 
 	for _, method := range stmt.methods {
 		funcType := FUNC_TYPE_METHOD
@@ -181,6 +205,30 @@ func (r *resolver) visitExpressionStmt(stmt *Expression[any]) error {
 	return err
 }
 
+func (r *resolver) visitForInStmt(stmt *ForIn[any]) error {
+	_, err := r.resolveExpression(stmt.iterable)
+	if err != nil {
+		return err
+	}
+
+	r.beginScope()
+	err = r.declare(stmt.name)
+	if err != nil {
+		return err
+	}
+	r.define(stmt.name)
+
+	r.loopDepth += 1
+	err = r.resolveStatement(stmt.body)
+	r.loopDepth -= 1
+	if err != nil {
+		return err
+	}
+	r.endScope()
+
+	return nil
+}
+
 func (r *resolver) visitFunctionStmt(funcStmt *Function[any]) error {
 	err := r.declare(funcStmt.name)
 	if err != nil {
@@ -197,6 +245,46 @@ func (r *resolver) visitGroupingExpr(e *Grouping[any]) (any, error) {
 	return nil, err
 }
 
+func (r *resolver) visitIndexExpr(e *Index[any]) (any, error) {
+	_, err := r.resolveExpression(e.object)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.resolveExpression(e.index)
+	return nil, err
+}
+
+func (r *resolver) visitIndexSetExpr(e *IndexSet[any]) (any, error) {
+	_, err := r.resolveExpression(e.value)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.resolveExpression(e.object)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.resolveExpression(e.index)
+	return nil, err
+}
+
+func (r *resolver) visitLambdaExpr(e *Lambda[any]) (any, error) {
+	return nil, r.resolveFunction(e.function, FUNC_TYPE_FUNCTION)
+}
+
+func (r *resolver) visitListExpr(e *List[any]) (any, error) {
+	for _, element := range e.elements {
+		_, err := r.resolveExpression(element)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
 func (r *resolver) visitIfStmt(stmt *If[any]) error {
 	_, err := r.resolveExpression(stmt.condition)
 	if err != nil {
@@ -228,6 +316,30 @@ func (r *resolver) visitLogicalExpr(e *Logical[any]) (any, error) {
 	return nil, err
 }
 
+func (r *resolver) visitMapExpr(e *Map[any]) (any, error) {
+	for i, key := range e.keys {
+		_, err := r.resolveExpression(key)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = r.resolveExpression(e.values[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *resolver) visitImportStmt(stmt *Import[any]) error {
+	if r.scopes.Len() > 0 {
+		return &ResolverError{t: stmt.keyword, message: "Can't import inside a block or function."}
+	}
+
+	return nil
+}
+
 func (r *resolver) visitPrintStmt(stmt *Print[any]) error {
 	_, err := r.resolveExpression(stmt.expression)
 	return err
@@ -248,6 +360,39 @@ func (r *resolver) visitReturnStmt(stmt *Return[any]) (err error) {
 	return err
 }
 
+func (r *resolver) visitThrowStmt(stmt *Throw[any]) error {
+	_, err := r.resolveExpression(stmt.value)
+	return err
+}
+
+func (r *resolver) visitTryStmt(stmt *Try[any]) error {
+	err := r.resolveStatement(stmt.tryBranch)
+	if err != nil {
+		return err
+	}
+
+	if stmt.catchBranch != nil {
+		r.beginScope()
+		err = r.declare(stmt.catchName)
+		if err != nil {
+			return err
+		}
+		r.define(stmt.catchName)
+
+		err = r.resolve(stmt.catchBranch.statements)
+		if err != nil {
+			return err
+		}
+		r.endScope()
+	}
+
+	if stmt.finallyBranch != nil {
+		return r.resolveStatement(stmt.finallyBranch)
+	}
+
+	return nil
+}
+
 func (r *resolver) visitUnaryExpr(e *Unary[any]) (any, error) {
 	_, err := r.resolveExpression(e.right)
 	return nil, err
@@ -271,9 +416,9 @@ func (r *resolver) visitVarStmt(s *Var[any]) (err error) {
 
 func (r *resolver) visitVariableExpr(e *Variable[any]) (any, error) {
 	if r.scopes.Len() > 0 {
-		scope := r.scopes.Back().Value.(map[string]bool)
+		scope := r.scopes.Back().Value.(map[string]*variable)
 		v, ok := scope[e.name.lexeme]
-		if ok && !v {
+		if ok && !v.defined {
 			return nil, &ParseError{e.name, "Can't read local variable in its own initializer."}
 		}
 	}
@@ -288,7 +433,16 @@ func (r *resolver) visitWhileStmt(whileStmt *While[any]) (err error) {
 		return
 	}
 
+	if whileStmt.increment != nil {
+		_, err = r.resolveExpression(whileStmt.increment)
+		if err != nil {
+			return
+		}
+	}
+
+	r.loopDepth += 1
 	err = r.resolveStatement(whileStmt.body)
+	r.loopDepth -= 1
 	return
 }
 
@@ -316,12 +470,12 @@ func (r *resolver) resolveLocal(expr Expr[any], name *token) {
 
 	i := scopesSize - 1
 	for elem := r.scopes.Back(); elem != nil; elem = elem.Prev() {
-		scope := elem.Value.(map[string]bool)
+		scope := elem.Value.(map[string]*variable)
 
-		_, ok := scope[name.lexeme]
+		v, ok := scope[name.lexeme]
 		if ok {
 			depth := scopesSize - 1 - i
-			r.i.resolve(expr, depth)
+			r.i.resolve(expr, depth, v.slot)
 			return
 		}
 		i = i - 1
@@ -332,6 +486,10 @@ func (r *resolver) resolveFunction(function *Function[any], funcType int) (err e
 	inEnclosingFuncType := r.inFuncType
 	r.inFuncType = funcType
 
+	// A loop around the declaration can't be broken out of from the body.
+	enclosingLoopDepth := r.loopDepth
+	r.loopDepth = 0
+
 	r.beginScope()
 	for _, param := range function.params {
 		err = r.declare(param)
@@ -347,12 +505,13 @@ func (r *resolver) resolveFunction(function *Function[any], funcType int) (err e
 	r.endScope()
 
 	r.inFuncType = inEnclosingFuncType
+	r.loopDepth = enclosingLoopDepth
 
 	return nil
 }
 
 func (r *resolver) beginScope() {
-	r.scopes.PushBack(map[string]bool{})
+	r.scopes.PushBack(map[string]*variable{})
 }
 
 func (r *resolver) endScope() {
@@ -364,12 +523,12 @@ func (r *resolver) declare(name *token) error {
 		return nil
 	}
 
-	scope := r.scopes.Back().Value.(map[string]bool)
+	scope := r.scopes.Back().Value.(map[string]*variable)
 	_, ok := scope[name.lexeme]
 	if ok {
 		return &ResolverError{t: name, message: "Already a variable with this name in this scope."}
 	}
-	scope[name.lexeme] = false
+	scope[name.lexeme] = &variable{defined: false, slot: len(scope)}
 
 	return nil
 }
@@ -379,6 +538,6 @@ func (r *resolver) define(name *token) {
 		return
 	}
 
-	scope := r.scopes.Back().Value.(map[string]bool)
-	scope[name.lexeme] = true
+	scope := r.scopes.Back().Value.(map[string]*variable)
+	scope[name.lexeme].defined = true
 }
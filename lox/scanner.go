@@ -1,24 +1,35 @@
-package main
+package lox
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"unicode/utf8"
 )
 
-type scanError struct {
-	line    int
+const unterminatedString = "Unterminated string."
+
+type ScanError struct {
+	t       *token
 	message string
 }
 
-func (err *scanError) Error() string {
-	return fmt.Sprintf("[line %v]: %v", err.line, err.message)
+func (err *ScanError) Error() string {
+	return fmt.Sprintf("[%v] Error: %v%v", err.t.position(), err.message, err.t.snippet())
 }
 
 type scanner struct {
 	source               string
 	tokens               []*token
+	errors               []*ScanError
 	start, current, line int
+
+	// The line on which the current lexeme starts.
+	startLine int
+	// The module being scanned, shown in errors, or empty for the main
+	// script.
+	file string
 }
 
 func newScanner(source string) *scanner {
@@ -28,21 +39,35 @@ func newScanner(source string) *scanner {
 	}
 }
 
+// scanTokens returns the tokens of the whole source. A lexical error is
+// recorded, and emitted as an ERROR token, before scanning carries on, so the
+// returned error joins every lexical error in the source.
 func (s *scanner) scanTokens() ([]*token, error) {
 	for !s.isAtEnd() {
 		// We are at the beginning of the next lexeme.
 		s.start = s.current
-		err := s.scanToken()
-		if err != nil {
-			return s.tokens, err
+		s.startLine = s.line
+		s.scanToken()
+	}
+
+	// Errors at the end point just past the last lexeme.
+	s.start = len(strings.TrimRight(s.source, " \r\t\n"))
+	s.current = s.start
+	s.startLine = strings.Count(s.source[:s.start], "\n") + 1
+	s.tokens = append(s.tokens, s.makeToken(EOF, nil))
+
+	if len(s.errors) > 0 {
+		errs := make([]error, len(s.errors))
+		for i, err := range s.errors {
+			errs[i] = err
 		}
+		return s.tokens, errors.Join(errs...)
 	}
 
-	s.tokens = append(s.tokens, newToken(EOF, "", nil, s.line))
 	return s.tokens, nil
 }
 
-func (s *scanner) scanToken() error {
+func (s *scanner) scanToken() {
 	c := s.advance()
 	switch c {
 	case "(":
@@ -53,6 +78,12 @@ func (s *scanner) scanToken() error {
 		s.addToken(LEFT_BRACE, nil)
 	case "}":
 		s.addToken(RIGHT_BRACE, nil)
+	case "[":
+		s.addToken(LEFT_BRACKET, nil)
+	case "]":
+		s.addToken(RIGHT_BRACKET, nil)
+	case ":":
+		s.addToken(COLON, nil)
 	case ",":
 		s.addToken(COMMA, nil)
 	case ".":
@@ -63,12 +94,25 @@ func (s *scanner) scanToken() error {
 		s.addToken(PLUS, nil)
 	case ";":
 		s.addToken(SEMICOLON, nil)
+	case "%":
+		s.addToken(PERCENT, nil)
+	case "~":
+		// Integer division is spelled ~/ because // starts a comment.
+		if s.match("/", TILDE_SLASH, -1) == TILDE_SLASH {
+			s.addToken(TILDE_SLASH, nil)
+		} else {
+			s.error("Unexpected character.")
+		}
 	case "*":
-		s.addToken(STAR, nil)
+		s.addToken(s.match("*", STAR_STAR, STAR), nil)
 	case "!":
 		s.addToken(s.match("=", BANG_EQUAL, BANG), nil)
 	case "=":
-		s.addToken(s.match("=", EQUAL_EQUAL, EQUAL), nil)
+		if s.match(">", ARROW, -1) == ARROW {
+			s.addToken(ARROW, nil)
+		} else {
+			s.addToken(s.match("=", EQUAL_EQUAL, EQUAL), nil)
+		}
 	case "<":
 		s.addToken(s.match("=", LESS_EQUAL, LESS), nil)
 	case ">":
@@ -94,16 +138,32 @@ func (s *scanner) scanToken() error {
 		} else if isAlpha(c) {
 			s.scanIdentifier()
 		} else {
-			return &scanError{s.line, "Unexpected character."}
+			s.error("Unexpected character.")
 		}
 	}
+}
 
-	return nil
+// error records a lexical error for the current lexeme and emits it as an
+// ERROR token.
+func (s *scanner) error(message string) {
+	t := s.makeToken(ERROR, message)
+	s.tokens = append(s.tokens, t)
+	s.errors = append(s.errors, &ScanError{t, message})
 }
 
 func (s *scanner) addToken(tokenType int, literal any) {
-	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, newToken(tokenType, text, literal, s.line))
+	s.tokens = append(s.tokens, s.makeToken(tokenType, literal))
+}
+
+func (s *scanner) makeToken(tokenType int, literal any) *token {
+	t := newToken(tokenType, s.source[s.start:s.current], literal, s.startLine)
+	lineStart := strings.LastIndexByte(s.source[:s.start], '\n') + 1
+	t.source = s.source
+	t.offset = s.start
+	t.column = utf8.RuneCountInString(s.source[lineStart:s.start]) + 1
+	t.file = s.file
+	t.length = s.current - s.start
+	return t
 }
 
 func (s *scanner) advance() string {
@@ -148,7 +208,7 @@ func (s *scanner) isAtEnd() bool {
 	return s.current >= len(s.source)
 }
 
-func (s *scanner) scanString() error {
+func (s *scanner) scanString() {
 	for s.peek() != "\"" && !s.isAtEnd() {
 		if s.peek() == "\n" {
 			s.line += 1
@@ -157,7 +217,8 @@ func (s *scanner) scanString() error {
 	}
 
 	if s.isAtEnd() {
-		return &scanError{s.line, "Unterminated string."}
+		s.error(unterminatedString)
+		return
 	}
 
 	// The closing ".
@@ -166,11 +227,9 @@ func (s *scanner) scanString() error {
 	// Trim the surrounding quotes.
 	value := s.source[s.start+1 : s.current-1]
 	s.addToken(STRING, value)
-
-	return nil
 }
 
-func (s *scanner) scanNumber() error {
+func (s *scanner) scanNumber() {
 	for isDigit(s.peek()) {
 		s.advance()
 	}
@@ -185,17 +244,26 @@ func (s *scanner) scanNumber() error {
 		}
 	}
 
+	// A number running into letters, such as 12ab, is malformed rather than
+	// a number followed by an identifier.
+	if isAlpha(s.peek()) {
+		for isAlphaNumeric(s.peek()) {
+			s.advance()
+		}
+		s.error("Malformed number.")
+		return
+	}
+
 	value, err := strconv.ParseFloat(s.source[s.start:s.current], 64)
 	if err != nil {
-		return &scanError{s.line, "Invalid numeric value"}
+		s.error("Invalid numeric value.")
+		return
 	}
 
 	s.addToken(NUMBER, value)
-
-	return nil
 }
 
-func (s *scanner) scanIdentifier() error {
+func (s *scanner) scanIdentifier() {
 	for isAlphaNumeric(s.peek()) {
 		s.advance()
 	}
@@ -206,8 +274,6 @@ func (s *scanner) scanIdentifier() error {
 		tokenType = IDENTIFIER
 	}
 	s.addToken(tokenType, nil)
-
-	return nil
 }
 
 func isDigit(c string) bool {
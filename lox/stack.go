@@ -0,0 +1,110 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StackFrame is a call to a Lox function or class that was in progress when
+// a runtime error occurred.
+type StackFrame struct {
+	// Function is the name of the called function, method or class.
+	Function string
+	// Line is the line of the call.
+	Line int
+	// File is the module containing the call, or empty for the main script.
+	File string
+}
+
+// StackTrace returns the calls in progress when the error occurred, the
+// outermost first.
+func (err *RuntimeError) StackTrace() []StackFrame {
+	return err.trace
+}
+
+// Traceback renders the stack trace in the style of Python, ending with the
+// line on which the error occurred. It is empty for an error in top-level
+// code.
+func (err *RuntimeError) Traceback() string {
+	if len(err.trace) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Traceback (most recent call last):\n")
+	for _, entry := range tracebackEntries(err.trace, err.t) {
+		fmt.Fprintf(&b, "  %v\n", entry)
+	}
+
+	return b.String()
+}
+
+// maxRepeatedEntries is how many identical entries in a row a traceback
+// shows before summarizing the rest, as Python does for deep recursion.
+const maxRepeatedEntries = 3
+
+// tracebackEntries describes each call in trace by the line it was made from
+// and the function containing that line, followed by the line of t, which
+// was reached in the innermost call.
+func tracebackEntries(trace []StackFrame, t *token) []string {
+	entries := make([]string, 0, len(trace)+1)
+	previous := ""
+	repeated := 0
+	add := func(entry string) {
+		if entry == previous {
+			repeated++
+			if repeated >= maxRepeatedEntries {
+				return
+			}
+		} else {
+			entries = appendRepeats(entries, repeated)
+			previous, repeated = entry, 0
+		}
+		entries = append(entries, entry)
+	}
+
+	caller := "<script>"
+	for _, frame := range trace {
+		add(fmt.Sprintf("%v, in %v", lineOf(frame.File, frame.Line), caller))
+		caller = frame.Function
+	}
+	add(fmt.Sprintf("%v, in %v", lineOf(t.file, t.line), caller))
+
+	return appendRepeats(entries, repeated)
+}
+
+// lineOf describes a line of file, which is empty for the main script.
+func lineOf(file string, line int) string {
+	if file == "" {
+		return fmt.Sprintf("line %v", line)
+	}
+
+	return fmt.Sprintf("line %v of %v", line, file)
+}
+
+// appendRepeats notes how many times the last entry was repeated beyond
+// those shown, if any were left out.
+func appendRepeats(entries []string, repeated int) []string {
+	if repeated < maxRepeatedEntries {
+		return entries
+	}
+
+	return append(entries, fmt.Sprintf("[Previous line repeated %v more times]", repeated-maxRepeatedEntries+1))
+}
+
+// callName names a callable in a stack frame, or returns false for callables
+// such as natives which have no frame of their own.
+func callName(function LoxCallable) (string, bool) {
+	switch function := function.(type) {
+	case *LoxFunction:
+		return function.name(), true
+	case *LoxClass:
+		return function.name, true
+	case *closure:
+		return function.fn.name, true
+	case *boundMethod:
+		return function.method.fn.name, true
+	}
+
+	return "", false
+}
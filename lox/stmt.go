@@ -1,4 +1,4 @@
-package main
+package lox
 
 type Stmt[T any] interface {
 	accept(v Visitor[T]) error
@@ -12,6 +12,14 @@ func (e *Block[T]) accept(v Visitor[T]) error {
 	return v.visitBlockStmt(e)
 }
 
+type Break[T any] struct {
+	keyword *token
+}
+
+func (e *Break[T]) accept(v Visitor[T]) error {
+	return v.visitBreakStmt(e)
+}
+
 type Class[T any] struct {
 	name *token
 	superclass *Variable[T]
@@ -22,6 +30,14 @@ func (e *Class[T]) accept(v Visitor[T]) error {
 	return v.visitClassStmt(e)
 }
 
+type Continue[T any] struct {
+	keyword *token
+}
+
+func (e *Continue[T]) accept(v Visitor[T]) error {
+	return v.visitContinueStmt(e)
+}
+
 type Expression[T any] struct {
 	expression Expr[T]
 }
@@ -30,6 +46,17 @@ func (e *Expression[T]) accept(v Visitor[T]) error {
 	return v.visitExpressionStmt(e)
 }
 
+type ForIn[T any] struct {
+	name *token
+	keyword *token
+	iterable Expr[T]
+	body Stmt[T]
+}
+
+func (e *ForIn[T]) accept(v Visitor[T]) error {
+	return v.visitForInStmt(e)
+}
+
 type Function[T any] struct {
 	name *token
 	params []*token
@@ -50,6 +77,16 @@ func (e *If[T]) accept(v Visitor[T]) error {
 	return v.visitIfStmt(e)
 }
 
+type Import[T any] struct {
+	keyword *token
+	names []*token
+	path *token
+}
+
+func (e *Import[T]) accept(v Visitor[T]) error {
+	return v.visitImportStmt(e)
+}
+
 type Print[T any] struct {
 	expression Expr[T]
 }
@@ -67,6 +104,26 @@ func (e *Return[T]) accept(v Visitor[T]) error {
 	return v.visitReturnStmt(e)
 }
 
+type Throw[T any] struct {
+	keyword *token
+	value Expr[T]
+}
+
+func (e *Throw[T]) accept(v Visitor[T]) error {
+	return v.visitThrowStmt(e)
+}
+
+type Try[T any] struct {
+	tryBranch *Block[T]
+	catchName *token
+	catchBranch *Block[T]
+	finallyBranch *Block[T]
+}
+
+func (e *Try[T]) accept(v Visitor[T]) error {
+	return v.visitTryStmt(e)
+}
+
 type Var[T any] struct {
 	name *token
 	initializer Expr[T]
@@ -79,6 +136,7 @@ func (e *Var[T]) accept(v Visitor[T]) error {
 type While[T any] struct {
 	condition Expr[T]
 	body Stmt[T]
+	increment Expr[T]
 }
 
 func (e *While[T]) accept(v Visitor[T]) error {
@@ -0,0 +1,208 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRepeatLength bounds the length in bytes of a string made by repeat, so
+// that a huge count is reported rather than exhausting memory.
+const maxRepeatLength = 1 << 30
+
+// stringMethod returns the method name of the string s. Strings are indexed
+// by character rather than by byte.
+func stringMethod(s string, name *token) (any, error) {
+	switch name.lexeme {
+	case "len":
+		return newBuiltinMethod("len", 0, func(v *interpreter, arguments []any) (any, error) {
+			return float64(utf8.RuneCountInString(s)), nil
+		}), nil
+	case "slice":
+		return newBuiltinMethod("slice", Variadic, func(v *interpreter, arguments []any) (any, error) {
+			runes := []rune(s)
+			start, end, err := sliceBounds(arguments, len(runes))
+			if err != nil {
+				return nil, err
+			}
+			return string(runes[start:end]), nil
+		}), nil
+	case "substr":
+		return newBuiltinMethod("substr", Variadic, func(v *interpreter, arguments []any) (any, error) {
+			if len(arguments) < 1 || len(arguments) > 2 {
+				return nil, fmt.Errorf("Expected 1 or 2 arguments but got %v.", len(arguments))
+			}
+
+			runes := []rune(s)
+			start, _, err := sliceBounds(arguments[:1], len(runes))
+			if err != nil {
+				return nil, err
+			}
+
+			end := len(runes)
+			if len(arguments) == 2 {
+				length, err := integerArgument(arguments, 1)
+				if err != nil {
+					return nil, err
+				}
+				end = min(start+max(length, 0), len(runes))
+			}
+			return string(runes[start:end]), nil
+		}), nil
+	case "indexOf":
+		return newBuiltinMethod("indexOf", 1, func(v *interpreter, arguments []any) (any, error) {
+			substr, err := stringArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			i := strings.Index(s, substr)
+			if i < 0 {
+				return float64(-1), nil
+			}
+			return float64(utf8.RuneCountInString(s[:i])), nil
+		}), nil
+	case "split":
+		return newBuiltinMethod("split", 1, func(v *interpreter, arguments []any) (any, error) {
+			sep, err := stringArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			parts := strings.Split(s, sep)
+			elements := make([]any, len(parts))
+			for i, part := range parts {
+				elements[i] = part
+			}
+			return newLoxList(elements), nil
+		}), nil
+	case "join":
+		return newBuiltinMethod("join", 1, func(v *interpreter, arguments []any) (any, error) {
+			list, ok := arguments[0].(*LoxList)
+			if !ok {
+				return nil, fmt.Errorf("Argument 1 must be a list but got %v.", typeName(arguments[0]))
+			}
+
+			parts := make([]string, len(list.elements))
+			for i, element := range list.elements {
+				parts[i] = stringify(element)
+			}
+			return strings.Join(parts, s), nil
+		}), nil
+	case "upper":
+		return newBuiltinMethod("upper", 0, func(v *interpreter, arguments []any) (any, error) {
+			return strings.ToUpper(s), nil
+		}), nil
+	case "lower":
+		return newBuiltinMethod("lower", 0, func(v *interpreter, arguments []any) (any, error) {
+			return strings.ToLower(s), nil
+		}), nil
+	case "trim":
+		return newBuiltinMethod("trim", 0, func(v *interpreter, arguments []any) (any, error) {
+			return strings.TrimSpace(s), nil
+		}), nil
+	case "replace":
+		return newBuiltinMethod("replace", 2, func(v *interpreter, arguments []any) (any, error) {
+			old, err := stringArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			replacement, err := stringArgument(arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			return strings.ReplaceAll(s, old, replacement), nil
+		}), nil
+	case "startsWith":
+		return newBuiltinMethod("startsWith", 1, func(v *interpreter, arguments []any) (any, error) {
+			prefix, err := stringArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return strings.HasPrefix(s, prefix), nil
+		}), nil
+	case "endsWith":
+		return newBuiltinMethod("endsWith", 1, func(v *interpreter, arguments []any) (any, error) {
+			suffix, err := stringArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return strings.HasSuffix(s, suffix), nil
+		}), nil
+	case "contains":
+		return newBuiltinMethod("contains", 1, func(v *interpreter, arguments []any) (any, error) {
+			substr, err := stringArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return strings.Contains(s, substr), nil
+		}), nil
+	case "repeat":
+		return newBuiltinMethod("repeat", 1, func(v *interpreter, arguments []any) (any, error) {
+			count, err := integerArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			if count < 0 {
+				return nil, fmt.Errorf("Repeat count must not be negative.")
+			}
+			if len(s) > 0 && count > maxRepeatLength/len(s) {
+				return nil, fmt.Errorf("Repeated string is too long.")
+			}
+			return strings.Repeat(s, count), nil
+		}), nil
+	case "charCodeAt":
+		return newBuiltinMethod("charCodeAt", 1, func(v *interpreter, arguments []any) (any, error) {
+			runes := []rune(s)
+			i, err := integerArgument(arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			if i < 0 {
+				i += len(runes)
+			}
+			if i < 0 || i >= len(runes) {
+				return nil, fmt.Errorf("String index %v out of range.", arguments[0])
+			}
+			return float64(runes[i]), nil
+		}), nil
+	}
+
+	return nil, &RuntimeError{t: name, message: fmt.Sprintf("Undefined property '%v'.", name.lexeme)}
+}
+
+// fromCharCode returns the string made of the characters with the given
+// codes.
+func fromCharCode(arguments []Value) (Value, error) {
+	runes := make([]rune, len(arguments))
+	for i := range arguments {
+		code, err := integerArgument(arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		if code < 0 || code > utf8.MaxRune {
+			return nil, fmt.Errorf("Invalid character code %v.", code)
+		}
+		runes[i] = rune(code)
+	}
+
+	return string(runes), nil
+}
+
+func stringArgument(arguments []any, i int) (string, error) {
+	s, ok := arguments[i].(string)
+	if !ok {
+		return "", fmt.Errorf("Argument %v must be a string but got %v.", i+1, typeName(arguments[i]))
+	}
+
+	return s, nil
+}
+
+func integerArgument(arguments []any, i int) (int, error) {
+	number, ok := arguments[i].(float64)
+	if !ok || number != float64(int(number)) {
+		return 0, fmt.Errorf("Argument %v must be an integer but got %v.", i+1, formatValue(arguments[i], map[any]bool{}))
+	}
+
+	return int(number), nil
+}
@@ -1,4 +1,4 @@
-package main
+package lox
 
 type Visitor[T any] interface {
 	visitAssignExpr(e *Assign[T]) (T, error)
@@ -6,8 +6,13 @@ type Visitor[T any] interface {
 	visitCallExpr(e *Call[T]) (T, error)
 	visitGetExpr(e *Get[T]) (T, error)
 	visitGroupingExpr(e *Grouping[T]) (T, error)
+	visitIndexExpr(e *Index[T]) (T, error)
+	visitIndexSetExpr(e *IndexSet[T]) (T, error)
+	visitLambdaExpr(e *Lambda[T]) (T, error)
+	visitListExpr(e *List[T]) (T, error)
 	visitLiteralExpr(e *Literal[T]) (T, error)
 	visitLogicalExpr(e *Logical[T]) (T, error)
+	visitMapExpr(e *Map[T]) (T, error)
 	visitSetExpr(e *Set[T]) (T, error)
 	visitSuperExpr(e *Super[T]) (T, error)
 	visitThisExpr(e *This[T]) (T, error)
@@ -15,12 +20,18 @@ type Visitor[T any] interface {
 	visitVariableExpr(e *Variable[T]) (T, error)
 
 	visitBlockStmt(s *Block[T]) error
+	visitBreakStmt(s *Break[T]) error
 	visitClassStmt(c *Class[T]) error
+	visitContinueStmt(s *Continue[T]) error
 	visitExpressionStmt(s *Expression[T]) error
+	visitForInStmt(s *ForIn[T]) error
 	visitFunctionStmt(s *Function[T]) error
 	visitIfStmt(ifStmt *If[T]) error
+	visitImportStmt(s *Import[T]) error
 	visitPrintStmt(s *Print[T]) error
 	visitReturnStmt(s *Return[T]) error
+	visitThrowStmt(s *Throw[T]) error
+	visitTryStmt(s *Try[T]) error
 	visitVarStmt(s *Var[T]) error
 	visitWhileStmt(s *While[T]) error
 }
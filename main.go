@@ -2,150 +2,192 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/snocorp/golox/lox"
+)
+
+// status is the outcome of running a script.
+type status int
+
+const (
+	statusOK status = iota
+	// The script could not be scanned, parsed, resolved or compiled.
+	statusCompileError
+	// The script stopped with a runtime error or an uncaught exception.
+	statusRuntimeError
 )
 
+// exitCode returns the exit code that reports s, following the reference
+// implementation's use of sysexits.h.
+func (s status) exitCode() int {
+	switch s {
+	case statusCompileError:
+		return 65
+	case statusRuntimeError:
+		return 70
+	}
+
+	return 0
+}
+
 type runner struct {
-	hadError bool
+	repl   bool
+	vm     *lox.VM
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func newRunner(stdout, stderr io.Writer) *runner {
+	vm := lox.New()
+	vm.SetOutput(stdout)
+	return &runner{vm: vm, stdout: stdout, stderr: stderr}
 }
 
 func main() {
-	args := os.Args[1:]
+	bytecode := flag.Bool("vm", false, "run scripts on the bytecode virtual machine")
+	flag.Parse()
+
+	configure := func(vm *lox.VM) {
+		// Modules that are not beside the importing script are looked for in
+		// the directories listed in LOXPATH.
+		vm.SetSearchPath(filepath.SplitList(os.Getenv("LOXPATH"))...)
+		if *bytecode {
+			vm.SetBackend(lox.Bytecode)
+		}
+	}
+
+	r := newRunner(os.Stdout, os.Stderr)
+	configure(r.vm)
+
+	args := flag.Args()
 	if len(args) > 2 {
-		fmt.Println("Usage: golox [run|print] [script]")
-		fmt.Println(args)
+		fmt.Fprintln(r.stderr, "Usage: golox [-vm] [run|print|bench|test] [script|directory]")
+		fmt.Fprintln(r.stderr, args)
 		os.Exit(64)
 	} else if len(args) == 2 {
 		if args[0] == "print" {
-			err := printFile(args[1])
+			r.exit(r.printFile(args[1]))
+		} else if args[0] == "bench" {
+			r.exit(r.benchFile(args[1], configure))
+		} else if args[0] == "test" {
+			passed, err := r.testDir(args[1], configure)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(r.stderr, err)
 				os.Exit(66)
 			}
-		} else {
-			err := runFile(args[1])
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(66)
+			if !passed {
+				os.Exit(1)
 			}
+		} else {
+			r.exit(r.runFile(args[1]))
 		}
+	} else if len(args) == 1 {
+		r.exit(r.runFile(args[0]))
 	} else {
-		runPrompt()
+		r.runPrompt(os.Stdin)
 	}
 }
 
-func printFile(path string) error {
-	bytes, err := os.ReadFile(path)
+// exit ends the process with the exit code for the outcome of a command, or
+// reports err, a failure to read the command's input.
+func (r *runner) exit(s status, err error) {
 	if err != nil {
-		return err
+		fmt.Fprintln(r.stderr, err)
+		os.Exit(66)
 	}
 
-	lox := &runner{}
-	source := string(bytes)
-	scanner := newScanner(source)
-	tokens, err := scanner.scanTokens()
-	if err != nil {
-		se, ok := err.(*scanError)
-		if ok {
-			lox.handleError(se.line, se.message)
-		} else {
-			lox.handleError(-1, err.Error())
-		}
-		return err
-	}
+	os.Exit(s.exitCode())
+}
 
-	parser := newParser[string](tokens)
-	statements, err := parser.parse()
+func (r *runner) printFile(path string) (status, error) {
+	bytes, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return statusOK, err
 	}
 
-	printer := &astPrinter{}
-	for _, s := range statements {
-		err = s.accept(printer)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return r.report(lox.PrintAST(string(bytes), r.stdout)), nil
 }
 
-func runFile(path string) error {
+// runFile runs the script at path. An error is returned only if the script
+// can't be read.
+func (r *runner) runFile(path string) (status, error) {
 	bytes, err := os.ReadFile(path)
 	if err != nil {
-		return err
-	}
-
-	lox := &runner{}
-	lox.run(string(bytes))
-
-	// Indicate an error in the exit code.
-	if lox.hadError {
-		os.Exit(65)
+		return statusOK, err
 	}
 
-	return nil
+	return r.run(string(bytes), path), nil
 }
 
-func runPrompt() {
-	input := bufio.NewReader(os.Stdin)
-
-	lox := &runner{}
+func (r *runner) runPrompt(in io.Reader) {
+	input := bufio.NewReader(in)
+	r.repl = true
 
+	buffer := ""
 	for {
-		fmt.Print("> ")
+		if buffer == "" {
+			fmt.Fprint(r.stdout, "> ")
+		} else {
+			fmt.Fprint(r.stdout, "... ")
+		}
 		line, err := input.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			fmt.Println(err)
+			fmt.Fprintln(r.stderr, err)
 		}
-		lox.run(line)
-		lox.hadError = false
-	}
-}
 
-func (lox *runner) run(source string) {
-	scanner := newScanner(source)
-	tokens, err := scanner.scanTokens()
-	if err != nil {
-		se, ok := err.(*scanError)
-		if ok {
-			lox.handleError(se.line, se.message)
-		} else {
-			lox.handleError(-1, err.Error())
+		if buffer == "" && strings.TrimSpace(line) == ":reset" {
+			r.vm.Reset()
+			continue
 		}
-		return
-	}
 
-	parser := newParser[any](tokens)
-	statements, err := parser.parse()
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
+		buffer += line
+		// A blank continuation line submits the input as it stands.
+		if strings.TrimSpace(line) != "" && !lox.IsComplete(buffer) {
+			continue
+		}
 
-	inter := newInterpreter()
-	resolver := newResolver(inter)
+		// An error is reported but doesn't end the session.
+		r.run(buffer, "")
+		buffer = ""
+	}
+}
 
-	err = resolver.resolve(statements)
-	if err != nil {
-		fmt.Println(err)
-		return
+// run runs source, which is either the script at path or input typed at the
+// prompt, and returns the outcome: whether it failed to compile or stopped
+// with a runtime error.
+func (r *runner) run(source string, path string) status {
+	if r.repl {
+		return r.report(r.vm.EvalInteractive(source))
 	}
 
-	inter.interpret(statements)
+	return r.report(r.vm.EvalFile(path, source))
 }
 
-func (lox *runner) handleError(line int, message string) {
-	lox.report(line, "", message)
-}
+// report writes err, if there is one, to stderr and returns the outcome it
+// represents.
+func (r *runner) report(err error) status {
+	if err == nil {
+		return statusOK
+	}
+	fmt.Fprintln(r.stderr, err)
+
+	var se *lox.ScanError
+	var pe *lox.ParseError
+	var re *lox.ResolverError
+	var ce *lox.CompileError
+	if errors.As(err, &se) || errors.As(err, &pe) || errors.As(err, &re) || errors.As(err, &ce) {
+		return statusCompileError
+	}
 
-func (lox *runner) report(line int, where, message string) {
-	fmt.Printf("[line %v] Error%v: %v\n", line, where, message)
-	lox.hadError = true
+	return statusRuntimeError
 }